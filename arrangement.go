@@ -0,0 +1,324 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Face is a connected region of the planar subdivision built by Arrangement.
+type Face struct {
+	// Contours is the boundary of the face: the outer contour first, in
+	// counter-clockwise order, followed by contours of its holes, in
+	// clockwise order.
+	Contours Polygon
+	// Covers lists the indices of input polygons covering the face, in
+	// ascending order.
+	Covers []int
+	// Sources[i][j] is the edge of an input polygon, with its index in
+	// Polygon, which the edge going from Contours[i][j] to the next point of
+	// the contour was cut from. Where edges of several polygons overlap,
+	// the edge is attributed to only one of them.
+	Sources [][]EdgeSource
+}
+
+// Arrangement computes the planar subdivision induced by all edges of polys,
+// and returns its faces covered by at least one of the polygons (each polygon
+// covering the region inside an odd number of its contours), labelled with
+// the sets of polygons covering them. Every point covered by any of the polys,
+// except for the boundaries, lies in exactly one face. The faces are sorted by
+// their Covers, and then by the lower left corners of their bounding boxes.
+//
+// All polygons are processed in a single sweep, like the two operands of
+// Construct, with each edge remembering which polygon it came from. This can
+// be used as a basis for dissolving polygons by attributes, or for finding
+// areas covered by at least k of them.
+func Arrangement(polys []Polygon) []Face {
+	c := &clipper{}
+	for i, p := range polys {
+		for k, cont := range p {
+			for j := range cont {
+				addProcessedSegment(&c.eventQueue, cont.segment(j), polygonType(i), EdgeSource{Polygon: i, Contour: k, Edge: j})
+			}
+		}
+	}
+
+	// edges of the faces, directed so that the faces lie on their left,
+	// and their sources, grouped by the sets of covering polygons
+	edges := map[string][]segment{}
+	sources := map[string][]EdgeSource{}
+	covers := map[string][]int{}
+	emit := func(set []int, s segment, source EdgeSource) {
+		if len(set) == 0 {
+			return
+		}
+		k := coverKey(set)
+		edges[k] = append(edges[k], s)
+		sources[k] = append(sources[k], source)
+		covers[k] = set
+	}
+
+	S := sweepline{}
+	for !c.eventQueue.IsEmpty() {
+		e := c.eventQueue.dequeue()
+		if e.left {
+			pos := S.insert(e)
+			// Segments starting at the same point are usually inserted from
+			// the bottom one up, but overlapping ones may come in any order,
+			// and a segment starting in the interior of another one is put
+			// on either side of it before dividing it, so the covers of all
+			// the segments starting at e.p are derived again from the one
+			// below them.
+			first := pos
+			for first > 0 && S[first-1].p.Equals(e.p) {
+				first--
+			}
+			for i := first; i < len(S) && (i <= pos || S[i].p.Equals(e.p)); i++ {
+				S[i].coverBelow = nil
+				if i > 0 {
+					S[i].coverBelow = S[i-1].coverAbove
+				}
+				S[i].coverAbove = toggleCover(S[i].coverBelow, int(S[i].polygonType))
+			}
+			if pos < len(S)-1 {
+				c.possibleIntersection(e, S[pos+1])
+			}
+			if pos > 0 {
+				c.possibleIntersection(S[pos-1], e)
+			}
+			continue
+		}
+
+		l := e.other
+		var prev, next *endpoint
+		otherPos := -1
+		for i := range S {
+			if S[i].equals(l) {
+				otherPos = i
+				break
+			}
+		}
+		if otherPos != -1 {
+			if otherPos > 0 {
+				prev = S[otherPos-1]
+			}
+			if otherPos < len(S)-1 {
+				next = S[otherPos+1]
+			}
+		}
+
+		// Overlapping edges of different polygons are divided into
+		// identical segments lying next to each other in S; only the last
+		// one removed is output, with the regions on both sides of them all.
+		switch {
+		case next != nil && sameSegment(next, l):
+			next.coverBelow = l.coverBelow
+		case prev != nil && sameSegment(prev, l):
+			prev.coverAbove = l.coverAbove
+		case !equalCovers(l.coverBelow, l.coverAbove):
+			emit(l.coverAbove, segment{l.p, e.p}, l.source)
+			emit(l.coverBelow, segment{e.p, l.p}, l.source)
+		}
+
+		if otherPos != -1 {
+			S.remove(S[otherPos])
+		}
+		if next != nil && prev != nil {
+			c.possibleIntersection(next, prev)
+		}
+	}
+
+	faces := []Face{}
+	for k, segs := range edges {
+		faces = append(faces, facesOf(segs, sources[k], covers[k])...)
+	}
+	sort.Slice(faces, func(i, j int) bool {
+		a, b := faces[i], faces[j]
+		if !equalCovers(a.Covers, b.Covers) {
+			return lessCovers(a.Covers, b.Covers)
+		}
+		pa, pb := a.Contours.BoundingBox().Min, b.Contours.BoundingBox().Min
+		if pa.X != pb.X {
+			return pa.X < pb.X
+		}
+		return pa.Y < pb.Y
+	})
+	return faces
+}
+
+// facesOf connects directed edges bounding regions covered by the same
+// polygons into contours, and groups them into faces. sources[i] is the
+// source of segs[i].
+func facesOf(segs []segment, sources []EdgeSource, covers []int) []Face {
+	type ring struct {
+		c       Contour
+		sources []EdgeSource
+	}
+	var outers, holes []ring
+	rings, edges := directedRings(segs)
+	for i, c := range rings {
+		r := ring{c, make([]EdgeSource, len(c))}
+		for j, k := range edges[i] {
+			r.sources[j] = sources[k]
+		}
+		if contourArea(c) > 0 {
+			outers = append(outers, r)
+		} else {
+			holes = append(holes, r)
+		}
+	}
+	sort.SliceStable(outers, func(i, j int) bool {
+		return contourArea(outers[i].c) < contourArea(outers[j].c)
+	})
+	faces := make([]Face, len(outers))
+	for i, r := range outers {
+		faces[i] = Face{Contours: Polygon{r.c}, Covers: covers, Sources: [][]EdgeSource{r.sources}}
+	}
+	for _, r := range holes {
+		h := r.c
+		// Contours of regions with the same cover can only touch at
+		// vertices, so the middle of an edge of the hole is strictly inside
+		// or outside each outer contour. The hole belongs to the smallest
+		// one containing it.
+		mid := Point{(h[0].X + h[1].X) / 2, (h[0].Y + h[1].Y) / 2}
+		for i, c := range outers {
+			if c.c.Contains(mid) {
+				faces[i].Contours.Add(h)
+				faces[i].Sources = append(faces[i].Sources, r.sources)
+				break
+			}
+		}
+	}
+	return faces
+}
+
+// directedRings connects the segments into closed contours, only linking
+// the end of a segment with the start of another one. A contour passing
+// twice through the same point is split there in two. edges[i][j] is the
+// index of the segment going from point j of contour i.
+func directedRings(segs []segment) (rings []Contour, edges [][]int) {
+	from := map[Point][]int{}
+	for i, s := range segs {
+		from[s.start] = append(from[s.start], i)
+	}
+	used := make([]bool, len(segs))
+	rings = []Contour{}
+	for i := range segs {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		ring, ringEdges := Contour{segs[i].start}, []int{i}
+		seen := map[Point]int{segs[i].start: 0}
+		p := segs[i].end
+		for {
+			if k, ok := seen[p]; ok {
+				// cut off the loop which has just closed
+				if len(ring)-k >= 3 {
+					rings = append(rings, ring[k:].Clone())
+					edges = append(edges, append([]int(nil), ringEdges[k:]...))
+				}
+				for _, q := range ring[k+1:] {
+					delete(seen, q)
+				}
+				ring, ringEdges = ring[:k+1], ringEdges[:k]
+				if k == 0 {
+					break
+				}
+			} else {
+				seen[p] = len(ring)
+				ring = append(ring, p)
+			}
+			next := -1
+			for _, j := range from[p] {
+				if !used[j] {
+					next = j
+					break
+				}
+			}
+			if next == -1 {
+				break // an open chain; shouldn't happen
+			}
+			used[next] = true
+			ringEdges = append(ringEdges, next)
+			p = segs[next].end
+		}
+	}
+	return rings, edges
+}
+
+// contourArea returns the signed area of c, positive if its points are in
+// counter-clockwise order.
+func contourArea(c Contour) float64 {
+	a := 0.0
+	for i := range c {
+		p, q := c[i], c[(i+1)%len(c)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
+
+func sameSegment(e1, e2 *endpoint) bool {
+	return e1.p.Equals(e2.p) && e1.other.p.Equals(e2.other.p)
+}
+
+// toggleCover returns a copy of the sorted set with id added, or removed if
+// it was already there
+func toggleCover(set []int, id int) []int {
+	i := sort.SearchInts(set, id)
+	result := make([]int, 0, len(set)+1)
+	result = append(result, set[:i]...)
+	if i < len(set) && set[i] == id {
+		return append(result, set[i+1:]...)
+	}
+	result = append(result, id)
+	return append(result, set[i:]...)
+}
+
+func equalCovers(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func lessCovers(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func coverKey(set []int) string {
+	buf := []byte{}
+	for _, id := range set {
+		buf = strconv.AppendInt(buf, int64(id), 10)
+		buf = append(buf, ',')
+	}
+	return string(buf)
+}
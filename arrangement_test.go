@@ -0,0 +1,144 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"fmt"
+	"math"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func square(x, y, size float64) polyclip.Polygon {
+	return polyclip.Polygon{{{X: x, Y: y}, {X: x + size, Y: y}, {X: x + size, Y: y + size}, {X: x, Y: y + size}}}
+}
+
+// checkFaces verifies that the faces cover each input polygon exactly, that
+// the contours of the faces are oriented as documented, and that their edges
+// lie on their source edges.
+func checkFaces(t *T, name string, polys []polyclip.Polygon, faces []polyclip.Face) {
+	covered := make([]float64, len(polys))
+	for _, f := range faces {
+		if len(f.Sources) != len(f.Contours) {
+			t.Errorf("%s: face %v: expected sources of %d contours, got: %d", name, f.Covers, len(f.Contours), len(f.Sources))
+			continue
+		}
+		for i, c := range f.Contours {
+			if len(f.Sources[i]) != len(c) {
+				t.Errorf("%s: face %v: expected %d sources of contour %d, got: %d", name, f.Covers, len(c), i, len(f.Sources[i]))
+				continue
+			}
+			for j, src := range f.Sources[i] {
+				cont := polys[src.Polygon][src.Contour]
+				a, b := cont[src.Edge], cont[(src.Edge+1)%len(cont)]
+				for _, p := range []polyclip.Point{c[j], c[(j+1)%len(c)]} {
+					if math.Abs((b.X-a.X)*(p.Y-a.Y)-(b.Y-a.Y)*(p.X-a.X)) > 1e-6*(1+math.Hypot(b.X-a.X, b.Y-a.Y)) {
+						t.Errorf("%s: face %v: edge %d of contour %d doesn't lie on its source %+v", name, f.Covers, j, i, src)
+					}
+				}
+			}
+		}
+		for i, c := range f.Contours {
+			area := 0.0
+			for j := range c {
+				p, q := c[j], c[(j+1)%len(c)]
+				area += p.X*q.Y - q.X*p.Y
+			}
+			if (i == 0) != (area > 0) {
+				t.Errorf("%s: face %v: wrong orientation of contour %d", name, f.Covers, i)
+			}
+		}
+		area := evenOddArea(f.Contours)
+		for _, i := range f.Covers {
+			covered[i] += area
+		}
+	}
+	for i, p := range polys {
+		if expected := evenOddArea(p); math.Abs(covered[i]-expected) > 1e-6 {
+			t.Errorf("%s: polygon %d: expected area %v covered by faces, got: %v", name, i, expected, covered[i])
+		}
+	}
+}
+
+func TestArrangement(t *T) {
+	cases := []struct {
+		name     string
+		polys    []polyclip.Polygon
+		expected string // Covers of the faces, and numbers of their contours
+	}{
+		{"overlapping", []polyclip.Polygon{square(0, 0, 2), square(1.25, 1.5, 2)}, "[0]:1 [0 1]:1 [1]:1"},
+		{"nested", []polyclip.Polygon{square(0, 0, 4), square(1, 1.5, 1)}, "[0]:2 [0 1]:1"},
+		{"disjoint", []polyclip.Polygon{square(0, 0, 1), square(3, 0, 1), {}}, "[0]:1 [1]:1"},
+		{"hole", []polyclip.Polygon{
+			append(square(0, 0, 4), square(1, 1, 2)[0]),
+			square(1.5, -1, 2.25),
+		}, "[0]:1 [0 1]:1 [1]:1 [1]:1"},
+		{"shared edge", []polyclip.Polygon{square(0, 0, 1), square(1, 0, 1)}, "[0]:1 [1]:1"},
+		{"identical", []polyclip.Polygon{
+			{{{X: 0, Y: 0}, {X: 3, Y: 1}, {X: 1, Y: 2}}},
+			{{{X: 0, Y: 0}, {X: 3, Y: 1}, {X: 1, Y: 2}}},
+		}, "[0 1]:1"},
+		{"three overlapping edges", []polyclip.Polygon{
+			{{{X: 0, Y: 3}, {X: 3, Y: 3}, {X: 3, Y: 4}, {X: 0, Y: 4}}},
+			{{{X: 0, Y: 4}, {X: 3, Y: 4}, {X: 3, Y: 5}, {X: 0, Y: 5}}},
+			{{{X: 1, Y: 4}, {X: 2, Y: 4}, {X: 2, Y: 7}, {X: 1, Y: 7}}},
+		}, "[0]:1 [1]:1 [1]:1 [1 2]:1 [2]:1"},
+		{"vertex on edge", []polyclip.Polygon{
+			{{{X: 2, Y: 3}, {X: 3, Y: 3}, {X: 1, Y: 4}, {X: 3, Y: 2}, {X: 4, Y: 2}}},
+		}, "[0]:1 [0]:1"},
+		{"overlapping from a vertex", []polyclip.Polygon{
+			{{{X: 3, Y: 0}, {X: 0, Y: 4}, {X: 0, Y: 3}}},
+			{{{X: 0, Y: 3}, {X: 2, Y: 1}, {X: 0, Y: 1}, {X: 3, Y: 3}}},
+		}, "[0]:1 [0]:1 [0 1]:1 [1]:1 [1]:1"},
+		{"vertex inside an edge", []polyclip.Polygon{
+			{{{X: 3, Y: 4}, {X: 4, Y: 3}, {X: 0, Y: 1}}},
+			{{{X: 0, Y: 1}, {X: 2, Y: 2}, {X: 2, Y: 0}, {X: 1, Y: 2}}},
+		}, "[0]:1 [0 1]:1 [1]:1"},
+	}
+	for _, c := range cases {
+		faces := polyclip.Arrangement(c.polys)
+		got := ""
+		for i, f := range faces {
+			if i > 0 {
+				got += " "
+			}
+			got += fmt.Sprintf("%v:%d", f.Covers, len(f.Contours))
+		}
+		if got != c.expected {
+			t.Errorf("%s: expected faces %s, got: %s", c.name, c.expected, got)
+		}
+		checkFaces(t, c.name, c.polys, faces)
+	}
+}
+
+func TestArrangementRandom(t *T) {
+	polys := footprints(60)
+	faces := polyclip.Arrangement(polys)
+	checkFaces(t, "footprints", polys, faces)
+
+	total := 0.0
+	for _, f := range faces {
+		total += evenOddArea(f.Contours)
+	}
+	if expected := rectanglesArea(polys); math.Abs(total-expected) > 1e-6 {
+		t.Errorf("Expected faces with total area %v, got: %v", expected, total)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"math"
+)
+
+// Attributes hold values attached to vertices of a polygon, like elevation
+// (Z) or measure (M): a[i][j] lists the values for point j of contour i.
+type Attributes [][][]float64
+
+// at returns the values of point j of contour i, or nil if there are none
+func (a Attributes) at(i, j int) []float64 {
+	if i >= len(a) || j >= len(a[i]) {
+		return nil
+	}
+	return a[i][j]
+}
+
+// ConstructAttributes works like Construct, but additionally computes
+// attributes of the vertices of the result, given the attributes of the
+// vertices of both polygons (either of which may be nil). Vertices of the
+// result which are vertices of an input polygon keep their values; values of
+// new vertices, created where edges are cut, are linearly interpolated
+// between the ends of the cut edge. Where an edge of the subject crosses an
+// edge of the clipping polygon, the values are taken from the subject, unless
+// it has no attributes. Vertices for which no attributes are known get nil.
+func (p PolygonOf[T]) ConstructAttributes(operation Op, clipping PolygonOf[T], subjectAttrs, clippingAttrs Attributes) (PolygonOf[T], Attributes) {
+	// interpolation is done at the points before rounding to integers
+	subject, clip := p.float(), clipping.float()
+	result, sources := subject.ConstructSources(operation, clip)
+	attrs := make(Attributes, len(result))
+	for i, c := range result {
+		attrs[i] = make([][]float64, len(c))
+		for k, pt := range c {
+			// the vertex lies on both its incoming and its outgoing edge;
+			// the subject's edges are tried first
+			in, out := sources[i][(k+len(c)-1)%len(c)], sources[i][k]
+			candidates := []EdgeSource{out, in}
+			if out.Clipping && !in.Clipping {
+				candidates = []EdgeSource{in, out}
+			}
+			for _, src := range candidates {
+				input, a := subject, subjectAttrs
+				if src.Clipping {
+					input, a = clip, clippingAttrs
+				}
+				if v := interpolateAttrs(pt, input[src.Contour], a, src); v != nil {
+					attrs[i][k] = v
+					break
+				}
+			}
+		}
+	}
+	return polygonOf[T](result), attrs
+}
+
+// interpolateAttrs computes values of attributes a at point pt lying on the
+// src edge of contour c, or returns nil if they're unknown.
+func interpolateAttrs(pt Point, c Contour, a Attributes, src EdgeSource) []float64 {
+	j := (src.Edge + 1) % len(c)
+	va, vb := a.at(src.Contour, src.Edge), a.at(src.Contour, j)
+	if va == nil || vb == nil {
+		return nil
+	}
+	start, end := c[src.Edge], c[j]
+	switch {
+	case pt.Equals(start):
+		return append([]float64(nil), va...)
+	case pt.Equals(end):
+		return append([]float64(nil), vb...)
+	}
+	// position of pt along the edge, from 0 at start to 1 at end
+	d := Point{end.X - start.X, end.Y - start.Y}
+	t := ((pt.X-start.X)*d.X + (pt.Y-start.Y)*d.Y) / (d.X*d.X + d.Y*d.Y)
+	t = math.Max(0, math.Min(1, t))
+	v := make([]float64, len(va))
+	for i := range v {
+		if i < len(vb) {
+			v[i] = va[i] + t*(vb[i]-va[i])
+		} else {
+			v[i] = va[i]
+		}
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"math"
+	"math/rand"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// linearAttrs returns attributes of vertices of p computed by f
+func linearAttrs(p polyclip.Polygon, f func(polyclip.Point) []float64) polyclip.Attributes {
+	a := polyclip.Attributes{}
+	for _, c := range p {
+		values := [][]float64{}
+		for _, pt := range c {
+			values = append(values, f(pt))
+		}
+		a = append(a, values)
+	}
+	return a
+}
+
+func TestConstructAttributes(t *T) {
+	// interpolation of linear functions along edges is exact
+	zm := func(p polyclip.Point) []float64 { return []float64{2*p.X + 3*p.Y + 1, p.X - p.Y} }
+	z := func(p polyclip.Point) []float64 { return []float64{5 - p.X} }
+	close := func(a, b []float64) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if math.Abs(a[i]-b[i]) > 1e-9 {
+				return false
+			}
+		}
+		return true
+	}
+
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 50; i++ {
+		subject, clipping := randomPolygon(r), randomPolygon(r)
+		for _, op := range []polyclip.Op{polyclip.UNION, polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.XOR} {
+			_, sources := subject.ConstructSources(op, clipping)
+
+			// attributes of the subject only
+			result, attrs := subject.ConstructAttributes(op, clipping, linearAttrs(subject, zm), nil)
+			for j, c := range result {
+				for k, pt := range c {
+					in, out := sources[j][(k+len(c)-1)%len(c)], sources[j][k]
+					if in.Clipping && out.Clipping {
+						if attrs[j][k] != nil {
+							t.Errorf("Case %d, op %v: vertex %v of clipping: expected no attributes, got: %v", i, op, pt, attrs[j][k])
+						}
+					} else if !close(attrs[j][k], zm(pt)) {
+						t.Errorf("Case %d, op %v: vertex %v: expected attributes %v, got: %v", i, op, pt, zm(pt), attrs[j][k])
+					}
+				}
+			}
+
+			// attributes of both polygons
+			result, attrs = subject.ConstructAttributes(op, clipping, linearAttrs(subject, zm), linearAttrs(clipping, z))
+			for j, c := range result {
+				for k, pt := range c {
+					in, out := sources[j][(k+len(c)-1)%len(c)], sources[j][k]
+					expected := zm(pt)
+					if in.Clipping && out.Clipping {
+						expected = z(pt)
+					}
+					if !close(attrs[j][k], expected) {
+						t.Errorf("Case %d, op %v: vertex %v: expected attributes %v, got: %v", i, op, pt, expected, attrs[j][k])
+					}
+				}
+			}
+		}
+	}
+}
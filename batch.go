@@ -0,0 +1,155 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// ClipOptions configure ClipMany and ClipStream.
+type ClipOptions struct {
+	// Workers is the number of goroutines clipping the subjects;
+	// runtime.GOMAXPROCS(0) is used if it's not positive.
+	Workers int
+}
+
+func (o ClipOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// ClipResult is a result of clipping a subject received by ClipStream.
+type ClipResult struct {
+	// Index is the position of the subject in the input channel,
+	// counting from 0.
+	Index   int
+	Polygon Polygon
+}
+
+// ClipMany performs the operation on each of the subjects and the same clip
+// polygon, concurrently, and returns the results in the order of subjects.
+// It's equivalent to calling subjects[i].Construct(op, clip) for each i, but
+// analyzes the clip polygon only once, with Prepare: for intersections and
+// differences, subjects whose bounding boxes don't overlap any edge of clip
+// are classified as lying entirely inside or outside it, and the ones which
+// don't take part in the result are dropped without running the full
+// algorithm. Other subjects are clipped with PreparedPolygon.Construct, only
+// using edges of clip near them.
+func ClipMany(subjects []Polygon, op Op, clip Polygon, opts ClipOptions) []Polygon {
+	results := make([]Polygon, len(subjects))
+	pc := Prepare(clip)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = clipPrepared(subjects[i], op, pc)
+			}
+		}()
+	}
+	for i := range subjects {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// ClipStream is a streaming variant of ClipMany: it performs the operation on
+// each subject received from subjects and the clip polygon, and sends the
+// results on the returned channel as soon as they're ready, so not
+// necessarily in order. The returned channel is closed after subjects is
+// closed and all results are sent, or after ctx is done, in which case the
+// remaining subjects and results are dropped. A consumer which stops reading
+// the results must cancel ctx, so that the goroutines of ClipStream exit.
+func ClipStream(ctx context.Context, subjects <-chan Polygon, op Op, clip Polygon, opts ClipOptions) <-chan ClipResult {
+	type job struct {
+		index   int
+		subject Polygon
+	}
+	out := make(chan ClipResult)
+	jobs := make(chan job)
+	pc := Prepare(clip)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				select {
+				case out <- ClipResult{j.index, clipPrepared(j.subject, op, pc)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := 0; ; i++ {
+			var s Polygon
+			var ok bool
+			select {
+			case s, ok = <-subjects:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job{i, s}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// clipPrepared performs the operation on subject and the prepared clip
+// polygon, classifying subjects which don't overlap any edges of clip as
+// lying entirely inside or outside it. Subjects taking part in the result
+// still go through Construct, which normalizes them.
+func clipPrepared(subject Polygon, op Op, clip *PreparedPolygon) Polygon {
+	if (op != INTERSECTION && op != DIFFERENCE) || subject.NumVertices() == 0 || len(clip.xs) == 0 {
+		return subject.Construct(op, clip.polygon)
+	}
+	bb := subject.BoundingBox()
+	if !bb.Overlaps(clip.bbox) || !clip.edgesNear(bb) {
+		// the whole subject is either inside or outside the clip
+		center := Point{(bb.Min.X + bb.Max.X) / 2, (bb.Min.Y + bb.Max.Y) / 2}
+		inside := bb.Overlaps(clip.bbox) && clip.ContainsPoint(center)
+		if inside != (op == INTERSECTION) {
+			return Polygon{}
+		}
+	}
+	return clip.Construct(op, subject)
+}
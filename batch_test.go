@@ -0,0 +1,151 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	. "testing"
+	"time"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// boundary is a clip polygon with a hole and a separate island
+var boundary = polyclip.Polygon{
+	{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}, {X: 50, Y: 80}, {X: 0, Y: 100}},
+	{{X: 40, Y: 40}, {X: 60, Y: 40}, {X: 60, Y: 60}, {X: 40, Y: 60}},
+	{{X: 150, Y: 10}, {X: 180, Y: 10}, {X: 180, Y: 40}},
+}
+
+// smallPolygons returns n small triangles scattered around boundary
+func smallPolygons(n int) []polyclip.Polygon {
+	r := rand.New(rand.NewSource(2))
+	polys := []polyclip.Polygon{}
+	for i := 0; i < n; i++ {
+		x, y := r.Float64()*220-20, r.Float64()*140-20
+		polys = append(polys, polyclip.Polygon{{
+			{X: x, Y: y}, {X: x + 1 + r.Float64()*5, Y: y + r.Float64()}, {X: x + r.Float64(), Y: y + 1 + r.Float64()*5},
+		}})
+	}
+	return polys
+}
+
+func TestClipMany(t *T) {
+	subjects := smallPolygons(500)
+	subjects = append(subjects, polyclip.Polygon{})
+	for _, op := range []polyclip.Op{polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.UNION, polyclip.XOR} {
+		results := polyclip.ClipMany(subjects, op, boundary, polyclip.ClipOptions{Workers: 4})
+		if len(results) != len(subjects) {
+			t.Fatalf("Op %v: expected %d results, got: %d", op, len(subjects), len(results))
+		}
+		for i, s := range subjects {
+			expected := evenOddArea(s.Construct(op, boundary))
+			if a := evenOddArea(results[i]); math.Abs(a-expected) > 1e-9 {
+				t.Errorf("Op %v, subject %d %v: expected area %v, got: %v", op, i, s, expected, a)
+			}
+		}
+	}
+
+	// a self-intersecting subject inside boundary is normalized by Construct
+	bowtie := polyclip.Polygon{{{X: 10, Y: 10}, {X: 20, Y: 20}, {X: 20, Y: 10}, {X: 10, Y: 20}}}
+	for _, op := range []polyclip.Op{polyclip.INTERSECTION, polyclip.DIFFERENCE} {
+		expected := bowtie.Construct(op, boundary)
+		result := polyclip.ClipMany([]polyclip.Polygon{bowtie}, op, boundary, polyclip.ClipOptions{})[0]
+		if len(result) != len(expected) || evenOddArea(result) != evenOddArea(expected) {
+			t.Errorf("Op %v: expected %v, got: %v", op, expected, result)
+		}
+	}
+
+	results := polyclip.ClipMany(subjects, polyclip.INTERSECTION, polyclip.Polygon{}, polyclip.ClipOptions{})
+	for i, r := range results {
+		if len(r) != 0 {
+			t.Errorf("Subject %d: expected empty intersection with empty clip, got: %v", i, r)
+		}
+	}
+}
+
+func TestClipStream(t *T) {
+	subjects := smallPolygons(200)
+	expected := polyclip.ClipMany(subjects, polyclip.INTERSECTION, boundary, polyclip.ClipOptions{Workers: 1})
+
+	in := make(chan polyclip.Polygon)
+	go func() {
+		for _, s := range subjects {
+			in <- s
+		}
+		close(in)
+	}()
+	got := make([]polyclip.Polygon, len(subjects))
+	n := 0
+	for r := range polyclip.ClipStream(context.Background(), in, polyclip.INTERSECTION, boundary, polyclip.ClipOptions{Workers: 3}) {
+		got[r.Index] = r.Polygon
+		n++
+	}
+	if n != len(subjects) {
+		t.Errorf("Expected %d results, got: %d", len(subjects), n)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected results of ClipMany, got: %v", got)
+	}
+}
+
+func TestClipStreamCancel(t *T) {
+	subjects := smallPolygons(200)
+	goroutines := runtime.NumGoroutine()
+
+	in := make(chan polyclip.Polygon, len(subjects))
+	for _, s := range subjects {
+		in <- s
+	}
+	close(in)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := polyclip.ClipStream(ctx, in, polyclip.INTERSECTION, boundary, polyclip.ClipOptions{Workers: 3})
+	<-out
+	// stop reading the results
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Errorf("Expected goroutines of ClipStream to exit, %d still running", n-goroutines)
+	}
+}
+
+func BenchmarkClipMany(b *B) {
+	subjects := smallPolygons(2000)
+	b.Run("Construct", func(b *B) {
+		for i := 0; i < b.N; i++ {
+			for _, s := range subjects {
+				s.Construct(polyclip.INTERSECTION, boundary)
+			}
+		}
+	})
+	b.Run("ClipMany", func(b *B) {
+		for i := 0; i < b.N; i++ {
+			polyclip.ClipMany(subjects, polyclip.INTERSECTION, boundary, polyclip.ClipOptions{})
+		}
+	})
+}
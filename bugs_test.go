@@ -190,3 +190,23 @@ func TestBug5(t *T) {
 		}
 	}
 }
+
+// TestXORTrivial checks the shortcuts taken for polygons with disjoint
+// bounding boxes, found by FuzzConstructFloat, and for empty polygons.
+func TestXORTrivial(t *T) {
+	subject := polyclip.Polygon{{{-1.5, 0.25}, {-1.5, 10.25}, {8.5, 10.25}, {8.5, 0.25}}}
+	clipping := polyclip.Polygon{{{0.5, -3}, {0.5, -1.75}, {1.75, -1.75}, {1.75, -3}}}
+	both := polyclip.Polygon{subject[0], clipping[0]}
+
+	cases := []struct{ subject, clipping, result polyclip.Polygon }{
+		{subject, clipping, both},
+		{subject, polyclip.Polygon{}, subject},
+		{polyclip.Polygon{}, clipping, clipping},
+	}
+	for i, c := range cases {
+		result := c.subject.Construct(polyclip.XOR, c.clipping)
+		if dump(result) != dump(c.result.Clone()) {
+			t.Errorf("case %d expected:\n%v\ngot:\n%v", i, dump(c.result.Clone()), dump(result))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"fmt"
+)
+
+// InvariantError is returned by ConstructChecked when an internal invariant
+// of the algorithm was found broken. As this always means a bug in the
+// library, the error contains enough details to help with diagnosing it.
+type InvariantError struct {
+	// Invariant describes the broken invariant.
+	Invariant string
+	// Event is the edge of the event which was being processed when the
+	// problem was detected, and Left tells if it was its left endpoint.
+	// Both are zero if the problem was detected after the sweep finished.
+	Event TraceEdge
+	Left  bool
+	// Neighbors lists the edges in the sweep line involved in the problem.
+	Neighbors []TraceEdge
+	// OpenChains lists the chains of result edges which could not be
+	// connected into closed contours.
+	OpenChains []Contour
+}
+
+func (e *InvariantError) Error() string {
+	s := "polyclip: broken invariant: " + e.Invariant
+	if e.Event != (TraceEdge{}) {
+		side := map[bool]string{true: "left", false: "right"}[e.Left]
+		s += fmt.Sprintf("\nevent: %s endpoint of %v-%v (clipping:%v %v)",
+			side, e.Event.Start, e.Event.End, e.Event.Clipping, e.Event.Kind)
+	}
+	for _, n := range e.Neighbors {
+		s += fmt.Sprintf("\nneighbor: %v-%v (clipping:%v %v)", n.Start, n.End, n.Clipping, n.Kind)
+	}
+	for _, c := range e.OpenChains {
+		s += fmt.Sprintf("\nopen chain: %v", c)
+	}
+	return s
+}
+
+// ConstructChecked works like Construct, but verifies internal invariants of
+// the algorithm after each processed event, and returns an *InvariantError
+// describing the first found violation, instead of silently producing a
+// broken result. The checks make it considerably slower than Construct, so it
+// is intended for debugging and testing.
+//
+// Checked are: ordering of the sweep line; lack of unreported crossings
+// between adjacent edges in the sweep line; consistency of left and right
+// endpoints of edges; and whether all edges of the result were connected into
+// closed contours. The points in the error have float64 coordinates, which
+// the algorithm works with.
+func (p PolygonOf[T]) ConstructChecked(operation Op, clipping PolygonOf[T]) (PolygonOf[T], error) {
+	c := clipper{
+		subject:  p.float(),
+		clipping: clipping.float(),
+		check:    true,
+	}
+	result := c.compute(operation)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return polygonOf[T](result), nil
+}
+
+func (c *clipper) checkInvariants(e *endpoint, S sweepline) error {
+	fail := func(invariant string, neighbors ...*endpoint) error {
+		err := &InvariantError{
+			Invariant: invariant,
+			Event:     TraceEdge{Start: e.p, End: e.other.p, Clipping: e.polygonType == _CLIPPING, Kind: EdgeKind(e.edgeType)},
+			Left:      e.left,
+		}
+		for _, n := range neighbors {
+			err.Neighbors = append(err.Neighbors, traceEdge(n))
+		}
+		return err
+	}
+
+	if e.other.other != e || e.left == e.other.left {
+		return fail("event not paired with its other endpoint", e.other)
+	}
+	for i, s := range S {
+		if !s.left || s.other.left || s.other.other != s {
+			return fail("edge in sweep line not paired properly", s)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := S[i-1]
+		if segmentCompare(s, prev) {
+			return fail("sweep line not sorted", prev, s)
+		}
+		if segmentsCross(prev.p, prev.other.p, s.p, s.other.p) {
+			return fail("unreported intersection of adjacent edges in sweep line", prev, s)
+		}
+	}
+	return nil
+}
+
+func (c *clipper) checkConnector(conn *connector) error {
+	if len(conn.openPolys) == 0 {
+		return nil
+	}
+	return &InvariantError{
+		Invariant:  fmt.Sprintf("%d chain(s) left open in connector", len(conn.openPolys)),
+		OpenChains: conn.openChains(),
+	}
+}
+
+// segmentsCross returns true if segments a0-a1 and b0-b1 properly cross, i.e.
+// have exactly one common point, which is not an endpoint of any of them.
+func segmentsCross(a0, a1, b0, b1 Point) bool {
+	sign := func(f float64) int {
+		switch {
+		case f > 0:
+			return 1
+		case f < 0:
+			return -1
+		}
+		return 0
+	}
+	d1 := sign(signedArea(a0, a1, b0))
+	d2 := sign(signedArea(a0, a1, b1))
+	d3 := sign(signedArea(b0, b1, a0))
+	d4 := sign(signedArea(b0, b1, a1))
+	return d1*d2 < 0 && d3*d4 < 0
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"strings"
+	. "testing"
+)
+
+func TestConstructChecked(t *T) {
+	cases := []struct {
+		subject, clipping Polygon
+		op                Op
+		invariant         string // empty if no error expected
+	}{
+		{
+			subject:  Polygon{{{1, 1}, {1, 3}, {3, 3}, {3, 1}}},
+			clipping: Polygon{{{2, 2}, {2, 4}, {4, 4}, {4, 2}}},
+			op:       UNION,
+		},
+		{
+			subject:  Polygon{{{1, 1}, {1, 3}, {3, 3}, {3, 1}}},
+			clipping: Polygon{{{2, 2}, {2, 4}, {4, 4}, {4, 2}}},
+			op:       XOR,
+		},
+		// github issue #3, simplified variant
+		{
+			subject: Polygon{{{1, 2}, {2, 2}, {2, 1}}},
+			clipping: Polygon{
+				{{2, 1}, {2, 2}, {3, 2}},
+				{{1, 2}, {2, 3}, {2, 2}},
+				{{2, 2}, {2, 3}, {3, 2}}},
+			op:        UNION,
+			invariant: "chain(s) left open in connector",
+		},
+	}
+	for i, c := range cases {
+		result, err := c.subject.ConstructChecked(c.op, c.clipping)
+		if c.invariant == "" {
+			verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+			expected := c.subject.Construct(c.op, c.clipping)
+			verify(t, len(result) == len(expected), "Case %d: expected %v, got %v", i, expected, result)
+			continue
+		}
+		ierr, ok := err.(*InvariantError)
+		verify(t, ok, "Case %d: expected *InvariantError, got: %v", i, err)
+		if ok {
+			verify(t, strings.Contains(ierr.Invariant, c.invariant), "Case %d: expected %q, got: %v", i, c.invariant, ierr)
+			verify(t, len(ierr.OpenChains) > 0, "Case %d: expected open chains in error, got: %v", i, ierr)
+		}
+	}
+}
+
+func TestCheckInvariants(t *T) {
+	mkseg := func(xl, yl, xr, yr float64) (left *endpoint) {
+		left = &endpoint{p: Point{xl, yl}, left: true, polygonType: _SUBJECT}
+		right := &endpoint{p: Point{xr, yr}, polygonType: _SUBJECT, other: left}
+		left.other = right
+		return left
+	}
+	low, high := mkseg(0, 0, 2, 0), mkseg(0, 1, 2, 1)
+	cross1, cross2 := mkseg(0, 0, 2, 2), mkseg(0, 2, 2, 0)
+	broken := mkseg(0, 3, 2, 3)
+	broken.other.other = nil
+
+	cases := []struct {
+		S         sweepline
+		invariant string // empty if no error expected
+	}{
+		{sweepline{low, high}, ""},
+		{sweepline{high, low}, "sweep line not sorted"},
+		{sweepline{cross1, cross2}, "unreported intersection"},
+		{sweepline{low, broken}, "not paired properly"},
+	}
+	for i, x := range cases {
+		c := clipper{}
+		err := c.checkInvariants(low, x.S)
+		if x.invariant == "" {
+			verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+			continue
+		}
+		verify(t, err != nil && strings.Contains(err.Error(), x.invariant), "Case %d: expected %q, got: %v", i, x.invariant, err)
+	}
+}
@@ -53,6 +53,26 @@ const (
 type clipper struct {
 	subject, clipping Polygon
 	eventQueue
+
+	// if not nil, these events, sorted like in the event queue, are used in
+	// the sweep instead of the edges of clipping; see PreparedPolygon
+	clippingEvents []*endpoint
+
+	trace   func(SweepStep) // if not nil, called after each processed event
+	divided []Point         // intersection points found while processing current event, for trace
+	check   bool            // if true, verify invariants after each processed event
+	err     error           // first broken invariant found when check is true
+
+	openChains []Contour // chains left open in the connector after the sweep
+
+	withSources bool           // if true, sources of the result edges are collected
+	sources     [][]EdgeSource // sources of the result edges, if withSources
+
+	// if true, the edges of the result are connected in areas instead of
+	// a connector, only to sum up the area of the result; trivial results
+	// aren't measured, so this is only useful for intersections
+	areaOnly bool
+	areas    areaConnector
 }
 
 func (c *clipper) compute(operation Op) Polygon {
@@ -61,14 +81,14 @@ func (c *clipper) compute(operation Op) Polygon {
 	if len(c.subject)*len(c.clipping) == 0 {
 		switch operation {
 		case DIFFERENCE:
-			return c.subject.Clone()
-		case UNION:
+			return c.trivial(true, false)
+		case UNION, XOR:
 			if len(c.subject) == 0 {
-				return c.clipping.Clone()
+				return c.trivial(false, true)
 			}
-			return c.subject.Clone()
+			return c.trivial(true, false)
 		}
-		return Polygon{}
+		return c.trivial(false, false)
 	}
 
 	// Test 2 for trivial result case
@@ -77,26 +97,26 @@ func (c *clipper) compute(operation Op) Polygon {
 	if !subjectbb.Overlaps(clippingbb) {
 		switch operation {
 		case DIFFERENCE:
-			return c.subject.Clone()
-		case UNION:
-			result := c.subject.Clone()
-			for _, cont := range c.clipping {
-				result.Add(cont.Clone())
-			}
-			return result
+			return c.trivial(true, false)
+		case UNION, XOR:
+			return c.trivial(true, true)
 		}
-		return Polygon{}
+		return c.trivial(false, false)
 	}
 
 	// Add each segment to the eventQueue, sorted from left to right.
-	for _, cont := range c.subject {
+	for j, cont := range c.subject {
 		for i := range cont {
-			addProcessedSegment(&c.eventQueue, cont.segment(i), _SUBJECT)
+			addProcessedSegment(&c.eventQueue, cont.segment(i), _SUBJECT, EdgeSource{Contour: j, Edge: i})
 		}
 	}
-	for _, cont := range c.clipping {
-		for i := range cont {
-			addProcessedSegment(&c.eventQueue, cont.segment(i), _CLIPPING)
+	if c.clippingEvents != nil {
+		c.eventQueue.merge(c.clippingEvents)
+	} else {
+		for j, cont := range c.clipping {
+			for i := range cont {
+				addProcessedSegment(&c.eventQueue, cont.segment(i), _CLIPPING, EdgeSource{Clipping: true, Contour: j, Edge: i})
+			}
 		}
 	}
 
@@ -119,6 +139,8 @@ func (c *clipper) compute(operation Op) Polygon {
 
 	for !c.eventQueue.IsEmpty() {
 		var prev, next *endpoint
+		var output []TraceEdge
+		c.divided = nil
 		e := c.eventQueue.dequeue()
 		_DBG(func() { fmt.Printf("\nProcess event: (of %d)\n%v\n", len(c.eventQueue.elements)+1, *e) })
 
@@ -127,7 +149,7 @@ func (c *clipper) compute(operation Op) Polygon {
 		case operation == INTERSECTION && e.p.X > MINMAX_X:
 			fallthrough
 		case operation == DIFFERENCE && e.p.X > subjectbb.Max.X:
-			return connector.toPolygon()
+			return c.result(&connector)
 			//case operation == UNION && e.p.X > MINMAX_X:
 			//	_DBG(func() { fmt.Print("\nUNION optimization, fast quit\n") })
 			//	// add all the non-processed line segments to the result
@@ -228,32 +250,33 @@ func (c *clipper) compute(operation Op) Polygon {
 			}
 
 			// Check if the line segment belongs to the Boolean operation
+			contributing := false
 			switch e.edgeType {
 			case _EDGE_NORMAL:
 				switch operation {
 				case INTERSECTION:
-					if e.other.inside {
-						connector.add(e.segment())
-					}
+					contributing = e.other.inside
 				case UNION:
-					if !e.other.inside {
-						connector.add(e.segment())
-					}
+					contributing = !e.other.inside
 				case DIFFERENCE:
-					if (e.polygonType == _SUBJECT && !e.other.inside) ||
-						(e.polygonType == _CLIPPING && e.other.inside) {
-						connector.add(e.segment())
-					}
+					contributing = (e.polygonType == _SUBJECT && !e.other.inside) ||
+						(e.polygonType == _CLIPPING && e.other.inside)
 				case XOR:
-					connector.add(e.segment())
+					contributing = true
 				}
 			case _EDGE_SAME_TRANSITION:
-				if operation == INTERSECTION || operation == UNION {
-					connector.add(e.segment())
-				}
+				contributing = operation == INTERSECTION || operation == UNION
 			case _EDGE_DIFFERENT_TRANSITION:
-				if operation == DIFFERENCE {
-					connector.add(e.segment())
+				contributing = operation == DIFFERENCE
+			}
+			if contributing {
+				if c.areaOnly {
+					c.areas.add(e.segment(), areaUnder(e, operation, subjectbb.Min.Y))
+				} else {
+					connector.add(e.segment(), e.source)
+				}
+				if c.trace != nil {
+					output = append(output, traceEdge(e))
 				}
 			}
 
@@ -274,10 +297,101 @@ func (c *clipper) compute(operation Op) Polygon {
 				fmt.Println(*e)
 			}
 		})
+
+		if c.trace != nil {
+			step := SweepStep{Event: e.p, Left: e.left, Divided: c.divided, Output: output}
+			for _, s := range S {
+				step.Active = append(step.Active, traceEdge(s))
+			}
+			c.trace(step)
+		}
+		if c.check {
+			c.err = c.checkInvariants(e, S)
+			if c.err != nil {
+				return nil
+			}
+		}
+	}
+	return c.result(&connector)
+}
+
+func (c *clipper) result(connector *connector) Polygon {
+	c.openChains = connector.openChains()
+	if c.check {
+		c.err = c.checkConnector(connector)
+	}
+	if c.withSources {
+		c.sources = connector.sources()
 	}
 	return connector.toPolygon()
 }
 
+// areaUnder returns the area between the segment of right event e, which is
+// an edge of the result of operation, and a horizontal line at y0. The area
+// is positive if the result lies just below the segment, and negative if it
+// lies above, so summing it over the edges of a contour of the result gives
+// the area of the contour, negative for holes.
+func areaUnder(e *endpoint, operation Op, y0 float64) float64 {
+	// is the region just below the segment inside its own polygon and
+	// inside the other one?
+	own, other := e.other.inout, e.other.inside
+	switch e.edgeType {
+	case _EDGE_SAME_TRANSITION:
+		other = own
+	case _EDGE_DIFFERENT_TRANSITION:
+		other = !own
+	}
+	subject, clipping := own, other
+	if e.polygonType == _CLIPPING {
+		subject, clipping = other, own
+	}
+	inResult := false
+	switch operation {
+	case INTERSECTION:
+		inResult = subject && clipping
+	case UNION:
+		inResult = subject || clipping
+	case DIFFERENCE:
+		inResult = subject && !clipping
+	case XOR:
+		inResult = subject != clipping
+	}
+
+	a := (e.p.X - e.other.p.X) * ((e.p.Y - y0) + (e.other.p.Y - y0)) / 2
+	if !inResult {
+		return -a
+	}
+	return a
+}
+
+// trivial returns a copy of the contours of the subject and/or clipping
+// polygon, as the result of an operation which doesn't need the sweep.
+func (c *clipper) trivial(subject, clipping bool) Polygon {
+	result := Polygon{}
+	if c.withSources {
+		c.sources = [][]EdgeSource{}
+	}
+	add := func(p Polygon, isClipping bool) {
+		for j, cont := range p {
+			result.Add(cont.Clone())
+			if c.withSources {
+				src := make([]EdgeSource, len(cont))
+				for i := range src {
+					src[i] = EdgeSource{Clipping: isClipping, Contour: j, Edge: i}
+				}
+				c.sources = append(c.sources, src)
+			}
+		}
+	}
+	if subject {
+		add(c.subject, false)
+	}
+	if clipping {
+		add(c.clipping, true)
+	}
+	return result
+}
+
 func findIntersection(seg0, seg1 segment) (int, Point, Point) {
 	var pi0, pi1 Point
 	p0 := seg0.start
@@ -288,8 +402,8 @@ func findIntersection(seg0, seg1 segment) (int, Point, Point) {
 	E := Point{p1.X - p0.X, p1.Y - p0.Y}
 	kross := d0.X*d1.Y - d0.Y*d1.X
 	sqrKross := kross * kross
-	sqrLen0 := d0.Length()
-	sqrLen1 := d1.Length()
+	sqrLen0 := d0.X*d0.X + d0.Y*d0.Y
+	sqrLen1 := d1.X*d1.X + d1.Y*d1.Y
 
 	if sqrKross > sqrEpsilon*sqrLen0*sqrLen1 {
 		// lines of the segments are not parallel
@@ -311,7 +425,7 @@ func findIntersection(seg0, seg1 segment) (int, Point, Point) {
 	}
 
 	// lines of the segments are parallel
-	sqrLenE := E.Length()
+	sqrLenE := E.X*E.X + E.Y*E.Y
 	kross = E.X*d0.Y - E.Y*d0.X
 	sqrKross = kross * kross
 	if sqrKross > sqrEpsilon*sqrLen0*sqrLenE {
@@ -394,6 +508,16 @@ func (c *clipper) possibleIntersection(e1, e2 *endpoint) {
 	}
 
 	if numIntersections == 1 {
+		// An intersection point computed again for another pair of segments
+		// may differ from an existing endpoint by a rounding error; dividing
+		// a segment so close to its end would produce a tiny segment, which
+		// could be divided back and forth forever.
+		for _, p := range []Point{e1.p, e1.other.p, e2.p, e2.other.p} {
+			if nearlyEqual(p, ip1) {
+				ip1 = p
+				break
+			}
+		}
 		if !e1.p.Equals(ip1) && !e1.other.p.Equals(ip1) {
 			// if ip1 is not an endpoint of the line segment associated to e1 then divide "e1"
 			c.divideSegment(e1, ip1)
@@ -481,33 +605,58 @@ func (c *clipper) possibleIntersection(e1, e2 *endpoint) {
 	c.divideSegment(sortedEvents[3].other, sortedEvents[2].p)
 }
 
+// nearlyEqual reports whether the coordinates of the points differ at most by
+// a few units in the last place
+func nearlyEqual(p1, p2 Point) bool {
+	const eps = 1e-14
+	return math.Abs(p1.X-p2.X) <= eps*math.Max(1, math.Abs(p1.X)) &&
+		math.Abs(p1.Y-p2.Y) <= eps*math.Max(1, math.Abs(p1.Y))
+}
+
 func (c *clipper) divideSegment(e *endpoint, p Point) {
+	if p.Equals(e.p) || p.Equals(e.other.p) {
+		return // would create a degenerate segment
+	}
 	// "Right event" of the "left line segment" resulting from dividing e (the line segment associated to e)
-	r := &endpoint{p: p, left: false, polygonType: e.polygonType, other: e, edgeType: e.edgeType}
+	r := &endpoint{p: p, left: false, polygonType: e.polygonType, other: e, edgeType: e.edgeType, source: e.source}
 	// "Left event" of the "right line segment" resulting from dividing e (the line segment associated to e)
-	l := &endpoint{p: p, left: true, polygonType: e.polygonType, other: e.other, edgeType: e.other.edgeType}
+	l := &endpoint{p: p, left: true, polygonType: e.polygonType, other: e.other, edgeType: e.other.edgeType, source: e.source}
 
 	if endpointLess(l, e.other) { // avoid a rounding error. The left event would be processed after the right event
 		// println("Oops")
 		e.other.left = true
-		e.left = false
+		l.left = false
 	}
 
 	e.other.other = l
 	e.other = r
 
+	if c.trace != nil {
+		c.divided = append(c.divided, p)
+	}
+
 	c.eventQueue.enqueue(l)
 	c.eventQueue.enqueue(r)
 }
 
-func addProcessedSegment(q *eventQueue, segment segment, polyType polygonType) {
+func addProcessedSegment(q *eventQueue, segment segment, polyType polygonType, source EdgeSource) {
 	if segment.start.Equals(segment.end) {
 		// Possible degenerate condition
 		return
 	}
 
-	e1 := &endpoint{p: segment.start, left: true, polygonType: polyType}
-	e2 := &endpoint{p: segment.end, left: true, polygonType: polyType, other: e1}
+	e1, e2 := newEndpoints(segment, polyType, source)
+
+	// Pushing it so the que is sorted from left to right, with object on the left having the highest priority
+	q.enqueue(e1)
+	q.enqueue(e2)
+}
+
+// newEndpoints returns the endpoints of a non-degenerate segment, at its start
+// and its end.
+func newEndpoints(segment segment, polyType polygonType, source EdgeSource) (*endpoint, *endpoint) {
+	e1 := &endpoint{p: segment.start, left: true, polygonType: polyType, source: source}
+	e2 := &endpoint{p: segment.end, left: true, polygonType: polyType, other: e1, source: source}
 	e1.other = e2
 
 	switch {
@@ -521,8 +670,5 @@ func addProcessedSegment(q *eventQueue, segment segment, polyType polygonType) {
 	default:
 		e1.left = false
 	}
-
-	// Pushing it so the que is sorted from left to right, with object on the left having the highest priority
-	q.enqueue(e1)
-	q.enqueue(e2)
+	return e1, e2
 }
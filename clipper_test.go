@@ -0,0 +1,110 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"math"
+	. "testing"
+)
+
+func TestFindIntersection(t *T) {
+	cases := []struct {
+		s0, s1 segment
+		n      int
+		p0, p1 Point
+	}{
+		{segment{Point{0, 0}, Point{2, 2}}, segment{Point{0, 2}, Point{2, 0}}, 1, Point{1, 1}, Point{}},
+		{segment{Point{0, 0}, Point{2, 0}}, segment{Point{0, 1}, Point{2, 1}}, 0, Point{}, Point{}},
+		{segment{Point{0, 4}, Point{3, 4}}, segment{Point{1, 4}, Point{2, 4}}, 2, Point{1, 4}, Point{2, 4}},
+		{segment{Point{0, 4}, Point{3, 4}}, segment{Point{2, 4}, Point{5, 4}}, 2, Point{2, 4}, Point{3, 4}},
+		{segment{Point{0, 3}, Point{3, 0}}, segment{Point{3, 0}, Point{5, -2}}, 1, Point{3, 0}, Point{}},
+		{segment{Point{0, 4}, Point{3, 4}}, segment{Point{4, 4}, Point{5, 4}}, 0, Point{}, Point{}},
+	}
+	for i, c := range cases {
+		n, p0, p1 := findIntersection(c.s0, c.s1)
+		verify(t, n == c.n && p0 == c.p0 && p1 == c.p1, "Case %d: expected %d %v %v, got: %d %v %v", i, c.n, c.p0, c.p1, n, p0, p1)
+	}
+}
+
+// newEdge returns the left endpoint of an edge from left to right.
+func newEdge(left, right Point, polyType polygonType) *endpoint {
+	e := &endpoint{p: left, left: true, polygonType: polyType}
+	e.other = &endpoint{p: right, other: e, polygonType: polyType}
+	return e
+}
+
+func TestPossibleIntersectionSnapping(t *T) {
+	// the crossing computed for these edges lies an ulp away from the left
+	// endpoint q of the second one, which mustn't get cut off as a tiny edge
+	q := Point{0.1, 0.9}
+	c := &clipper{}
+	c.possibleIntersection(newEdge(Point{0, 0}, Point{1, 9}, _SUBJECT), newEdge(q, Point{1.1, -2.1}, _CLIPPING))
+	verify(t, len(c.eventQueue.elements) == 2, "Expected only the first edge divided, got events: %v", c.eventQueue.elements)
+	for _, e := range c.eventQueue.elements {
+		verify(t, e.p == q, "Expected division at %v, got %v", q, e.p)
+	}
+}
+
+func TestDivideSegmentAtEndpoint(t *T) {
+	c := &clipper{}
+	e := newEdge(Point{0, 0}, Point{2, 1}, _SUBJECT)
+	c.divideSegment(e, e.p)
+	c.divideSegment(e, e.other.p)
+	verify(t, len(c.eventQueue.elements) == 0 && e.other.other == e, "Expected no zero-length edges, got events: %v", c.eventQueue.elements)
+}
+
+func TestDivideSegmentRounding(t *T) {
+	// the vertex of the octagon nearest to the left side of the square lies
+	// a hair right of it, so dividing the side there turns its upper part
+	// around
+	octagon := Contour{}
+	for k := 0; k < 8; k++ {
+		a := math.Pi * float64(k) / 4
+		octagon.Add(Point{math.Cos(a), math.Sin(a)})
+	}
+	square := Polygon{{{0, 0}, {2, 0}, {2, 2}, {0, 2}}}
+	result, err := Polygon{octagon}.ConstructChecked(INTERSECTION, square)
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	verify(t, len(result) == 1 && len(result[0]) == 4, "Expected a quadrilateral, got: %v", result)
+}
+
+func TestPreparedSweepEvents(t *T) {
+	// horizontal stripes, with a subject inside one of them
+	clip := Polygon{}
+	for y := 0.0; y < 200; y += 10 {
+		clip.Add(Contour{{0, y}, {100, y}, {100, y + 5}, {0, y + 5}})
+	}
+	subject := Polygon{{{40, 51}, {60, 51}, {60, 54}, {40, 54}}}
+	pp := Prepare(clip)
+	sb := subject.BoundingBox()
+	events := pp.sweepEvents(window{before(pp.xs, sb.Min.X), sb.Max.X, before(pp.ys, sb.Min.Y), after(pp.ys, sb.Max.Y)})
+	// only the bottom of the window, inside the stripe, is left
+	if len(events) != 2 || events[0].segment() != (segment{Point{100, 50.5}, Point{20, 50.5}}) {
+		t.Errorf("Expected a single segment below subject, got: %v", events)
+	}
+	if result := pp.Construct(INTERSECTION, subject); len(result) != 1 || len(result[0]) != 4 {
+		t.Errorf("Expected subject in the result, got: %v", result)
+	}
+	// all the edges are used in unions, as they are
+	inf := math.Inf(1)
+	if events := pp.sweepEvents(window{-inf, inf, -inf, inf}); len(events) != 4*2*len(clip) {
+		t.Errorf("Expected all %d edges, got %d events", 4*len(clip), len(events))
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command polyclip-shrink minimizes a pair of polygons on which polyclip
+// misbehaves, and prints the result as a Go test case, ready to be pasted into
+// bugs_test.go.
+//
+// Usage:
+//
+//	polyclip-shrink [flags] subject.txt clipping.txt
+//
+// The polygons are read in the textual format of polyutil.EncodePolygon.
+// Flags:
+//
+//	-op union|intersection|difference|xor
+//		operation to perform (default union)
+//	-pred open|check|oracle
+//		what is considered a failure: result having open chains (default),
+//		broken invariants reported by ConstructChecked, or result differing
+//		from the one expected by the rasterizing oracle in package test
+//	-res N
+//		resolution used by the oracle (default 200)
+//	-tolerance F
+//		fraction of the area of inputs which can be mismatched before the
+//		oracle reports failure (default 0.001)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/akavel/polyclip-go"
+	"github.com/akavel/polyclip-go/polyutil"
+	"github.com/akavel/polyclip-go/test"
+)
+
+var ops = map[string]polyclip.Op{
+	"union":        polyclip.UNION,
+	"intersection": polyclip.INTERSECTION,
+	"difference":   polyclip.DIFFERENCE,
+	"xor":          polyclip.XOR,
+}
+
+func main() {
+	var (
+		opName    = flag.String("op", "union", "operation: union, intersection, difference or xor")
+		pred      = flag.String("pred", "open", "failure predicate: open, check or oracle")
+		res       = flag.Int("res", 200, "resolution of the oracle")
+		tolerance = flag.Float64("tolerance", 0.001, "fraction of area which can be mismatched according to the oracle")
+	)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: polyclip-shrink [flags] subject.txt clipping.txt")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	op, ok := ops[*opName]
+	if !ok {
+		die(fmt.Errorf("unknown operation: %q", *opName))
+	}
+
+	subject, err := load(flag.Arg(0))
+	if err != nil {
+		die(err)
+	}
+	clipping, err := load(flag.Arg(1))
+	if err != nil {
+		die(err)
+	}
+
+	var fails func(subject, clipping polyclip.Polygon) bool
+	switch *pred {
+	case "open":
+		fails = func(subject, clipping polyclip.Polygon) bool {
+			return subject.ConstructResult(op, clipping).Err() != nil
+		}
+	case "check":
+		fails = func(subject, clipping polyclip.Polygon) bool {
+			_, err := subject.ConstructChecked(op, clipping)
+			return err != nil
+		}
+	case "oracle":
+		fails = func(subject, clipping polyclip.Polygon) bool {
+			result := subject.Construct(op, clipping)
+			m := test.Oracle(subject, op, clipping, result, *res)
+			total := test.Oracle(subject, polyclip.UNION, clipping, polyclip.Polygon{}, *res).Area
+			return m.Area > *tolerance*total
+		}
+	default:
+		die(fmt.Errorf("unknown predicate: %q", *pred))
+	}
+
+	if !fails(subject, clipping) {
+		die(fmt.Errorf("the input polygons don't fail with -pred=%s", *pred))
+	}
+	n := subject.NumVertices() + clipping.NumVertices()
+	subject, clipping = polyutil.Shrink(subject, clipping, fails)
+	fmt.Fprintf(os.Stderr, "shrunk from %d to %d vertices\n", n, subject.NumVertices()+clipping.NumVertices())
+
+	fmt.Printf("\t\t// %s, found by polyclip-shrink -pred=%s\n", *opName, *pred)
+	fmt.Printf("\t\t{\n")
+	fmt.Printf("\t\t\tsubject:  %s,\n", polyutil.GoLiteral(subject))
+	fmt.Printf("\t\t\tclipping: %s,\n", polyutil.GoLiteral(clipping))
+	fmt.Printf("\t\t},\n")
+}
+
+func load(path string) (polyclip.Polygon, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := polyutil.DecodePolygon(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return *p, nil
+}
+
+func die(err error) {
+	fmt.Fprintln(os.Stderr, "polyclip-shrink:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/akavel/polyclip-go"
+	"github.com/akavel/polyclip-go/polyutil"
+	"github.com/akavel/polyclip-go/render"
+)
+
+// formats maps file name extensions to names of formats
+var formats = map[string]string{
+	".gpc":     "gpc",
+	".txt":     "gpc",
+	".wkt":     "wkt",
+	".wkb":     "wkb",
+	".hex":     "hexwkb",
+	".geojson": "geojson",
+	".json":    "geojson",
+	".svg":     "svg",
+	".plyc":    "binary",
+	".bin":     "binary",
+	".png":     "png",
+}
+
+// formatOf returns the format of a file based on its extension, or "" if it's
+// not known.
+func formatOf(path string) string {
+	return formats[strings.ToLower(filepath.Ext(path))]
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// detect guesses the format of data by its contents.
+func detect(data []byte) (string, error) {
+	if bytes.HasPrefix(data, []byte("PLYC")) {
+		return "binary", nil
+	}
+	if len(data) >= 9 && (data[0] == 0 || data[0] == 1) {
+		return "wkb", nil
+	}
+	text := strings.TrimSpace(string(data))
+	first := text
+	if i := strings.IndexAny(text, " \t\r\n"); i >= 0 {
+		first = text[:i]
+	}
+	upper := strings.ToUpper(first)
+	switch {
+	case text == "":
+		return "", errors.New("empty input")
+	case text[0] == '{':
+		return "geojson", nil
+	case text[0] == '<':
+		return "svg", nil
+	case len(first) >= 18 && isHex(first) && (strings.HasPrefix(first, "00") || strings.HasPrefix(first, "01")):
+		return "hexwkb", nil
+	case strings.HasPrefix(upper, "POLYGON") || strings.HasPrefix(upper, "MULTIPOLYGON") || strings.HasPrefix(upper, "SRID"):
+		return "wkt", nil
+	case strings.IndexByte("0123456789", text[0]) >= 0:
+		return "gpc", nil
+	}
+	return "", errors.New("unknown input format")
+}
+
+// skipSpace consumes whitespace from r, and reports whether there's anything
+// more to read.
+func skipSpace(r *bufio.Reader) bool {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return false
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(c)) {
+			r.UnreadByte()
+			return true
+		}
+	}
+}
+
+// unionAll merges polygons read from a single file into one.
+func unionAll(polys []polyclip.Polygon) polyclip.Polygon {
+	result := polyclip.Polygon{}
+	for i, p := range polys {
+		if i == 0 {
+			result = p
+		} else {
+			result = result.Construct(polyclip.UNION, p)
+		}
+	}
+	return result
+}
+
+// decode reads a polygon in the specified format. Files containing many
+// polygons (or features) give the union of all of them. tolerance is used
+// for flattening curves in SVG files.
+func decode(data []byte, format string, tolerance float64) (polyclip.Polygon, error) {
+	r := bytes.NewReader(data)
+	var polys []polyclip.Polygon
+	switch format {
+	case "gpc":
+		var err error
+		if polys, err = polyutil.DecodePolygons(r); err != nil {
+			return nil, err
+		}
+	case "wkt":
+		br := bufio.NewReader(r)
+		for {
+			p, err := polyutil.ParseWKT(br)
+			if err != nil {
+				return nil, err
+			}
+			polys = append(polys, p)
+			if !skipSpace(br) {
+				break
+			}
+		}
+	case "wkb", "hexwkb":
+		var in io.Reader = r
+		if format == "hexwkb" {
+			in = hex.NewDecoder(strings.NewReader(strings.TrimSpace(string(data))))
+		}
+		p, _, err := polyutil.DecodeWKB(in)
+		if err != nil {
+			return nil, err
+		}
+		polys = append(polys, p)
+	case "geojson":
+		features, err := polyutil.DecodeGeoJSON(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range features {
+			polys = append(polys, f.Polygon)
+		}
+	case "svg":
+		var err error
+		if polys, err = polyutil.DecodeSVG(r, tolerance); err != nil {
+			return nil, err
+		}
+	case "binary":
+		d := polyutil.NewBinaryDecoder(r)
+		for {
+			p, err := d.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			polys = append(polys, p)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported input format %q", format)
+	}
+	return unionAll(polys), nil
+}
+
+// encode writes result in the specified format. Inputs are drawn together
+// with the result in PNG and SVG images; size is the width of PNG images.
+func encode(w io.Writer, format string, result polyclip.Polygon, inputs []polyclip.Polygon, size int) error {
+	switch format {
+	case "gpc":
+		return polyutil.EncodeRings(w, polyutil.ClassifyRings(result))
+	case "wkt":
+		if err := polyutil.FormatWKT(w, result); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	case "wkb":
+		return polyutil.EncodeWKB(w, result, binary.LittleEndian, 0)
+	case "hexwkb":
+		buf := &bytes.Buffer{}
+		if err := polyutil.EncodeWKB(buf, result, binary.LittleEndian, 0); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%X\n", buf.Bytes())
+		return err
+	case "geojson":
+		return polyutil.EncodeGeoJSON(w, polyutil.GeoJSONFeature{Polygon: result})
+	case "binary":
+		return polyutil.EncodeBinary(w, result, 0)
+	case "svg":
+		layers := []polyutil.SVGLayer{}
+		for i, p := range append(inputs, result) {
+			style := "fill:none;stroke:#888;stroke-dasharray:4"
+			if i == len(inputs) {
+				style = "fill:#00f;fill-opacity:0.4;stroke:#00f"
+			}
+			layers = append(layers, polyutil.SVGLayer{Polygon: p, Style: style + ";vector-effect:non-scaling-stroke"})
+		}
+		return polyutil.EncodeSVG(w, layers...)
+	case "png":
+		layers := []render.Layer{}
+		for i, p := range inputs {
+			layers = append(layers, render.Layer{Polygon: p, Style: render.Style{Stroke: render.DefaultStyle(i + 1).Stroke}})
+		}
+		layers = append(layers, render.Layer{Polygon: result, Style: render.DefaultStyle(0)})
+		opts := render.Options{Width: size, Height: size, Margin: 10, FlipY: true}
+		return render.EncodePNG(w, opts, layers...)
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestDetect(t *T) {
+	cases := []struct {
+		data, format string
+	}{
+		{"1\n3 0\n0 0\n1 0\n1 1\n", "gpc"},
+		{"  POLYGON ((0 0, 1 0, 1 1, 0 0))", "wkt"},
+		{"srid=4326;MultiPolygon EMPTY", "wkt"},
+		{"010300000000000000", "hexwkb"},
+		{"\x01\x03\x00\x00\x00\x00\x00\x00\x00", "wkb"},
+		{`{"type": "Polygon", "coordinates": []}`, "geojson"},
+		{`<?xml version="1.0"?><svg/>`, "svg"},
+		{"PLYC\x01\x00", "binary"},
+	}
+	for i, c := range cases {
+		format, err := detect([]byte(c.data))
+		verify(t, err == nil && format == c.format, "Case %d: expected %s, got: %s %v", i, c.format, format, err)
+	}
+	for _, data := range []string{"", "  \n", "hello"} {
+		_, err := detect([]byte(data))
+		verify(t, err != nil, "Expected error for %q", data)
+	}
+}
+
+func TestEncodeDecode(t *T) {
+	p := polyclip.Polygon{
+		{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}},
+		{{X: 2, Y: 2}, {X: 2, Y: 8}, {X: 8, Y: 8}, {X: 8, Y: 2}},
+	}
+	for _, format := range []string{"gpc", "wkt", "wkb", "hexwkb", "geojson", "binary"} {
+		buf := &bytes.Buffer{}
+		err := encode(buf, format, p, nil, 0)
+		verify(t, err == nil, "%s: expected no error encoding, got: %v", format, err)
+		detected, err := detect(buf.Bytes())
+		verify(t, err == nil && detected == format, "%s: detected as %s %v", format, detected, err)
+		q, err := decode(buf.Bytes(), format, 0.01)
+		verify(t, err == nil && fmt.Sprint(q) == fmt.Sprint(p), "%s: expected %v, got: %v %v", format, p, q, err)
+	}
+
+	// many polygons in one file are merged
+	q, err := decode([]byte("POLYGON ((0 0, 2 0, 2 2, 0 2))\nPOLYGON ((1 1, 3 1, 3 3, 1 3))\n"), "wkt", 0)
+	verify(t, err == nil && len(q) == 1 && len(q[0]) == 8, "Expected union of polygons, got: %v %v", q, err)
+}
+
+func verify(t *T, cond bool, format string, args ...interface{}) {
+	if !cond {
+		t.Errorf(format, args...)
+	}
+}
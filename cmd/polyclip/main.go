@@ -0,0 +1,258 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command polyclip performs Boolean operations on polygons stored in files.
+//
+// Usage:
+//
+//	polyclip [flags] subject [clipping]
+//
+// For example:
+//
+//	polyclip -op union a.gpc b.wkt -o out.geojson
+//
+// Input formats are detected from the contents of the files: the textual
+// format of polyutil.EncodePolygon (compatible with GPC), WKT, WKB (raw or
+// hex-encoded), GeoJSON, SVG and the binary format of polyutil.EncodeBinary.
+// A file containing many polygons is read as their union. The output format
+// is taken from the extension of the output file (.gpc, .txt, .wkt, .wkb,
+// .hex, .geojson, .json, .svg, .plyc, .bin or .png), or from the -format
+// flag. PNG and SVG outputs show the inputs together with the result, for
+// quick inspection.
+//
+// Flags:
+//
+//	-op union|intersection|difference|xor
+//		operation to perform on subject and clipping; without it, a single
+//		input is converted, simplified or checked
+//	-o FILE
+//		output file (default: standard output)
+//	-format FORMAT
+//		output format: gpc, wkt, wkb, hexwkb, geojson, svg, binary or png
+//		(default: based on the extension of the output file, or the format
+//		of subject)
+//	-validate
+//		check inputs for malformed contours, and the operation for broken
+//		invariants (see polyclip.Polygon.ConstructChecked); exits with
+//		status 1 if problems are found
+//	-simplify TOLERANCE
+//		simplify the result, removing vertices closer than TOLERANCE to
+//		their neighbors' segments
+//	-area
+//		report areas of inputs and result
+//	-tolerance TOLERANCE
+//		maximum error of flattening curves in SVG inputs (default 0.01)
+//	-size N
+//		width and height of PNG output, in pixels (default 512)
+//
+// Reports of -validate and -area are printed to standard output if no output
+// file is specified, in which case the result itself is not written;
+// otherwise they are printed to standard error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/akavel/polyclip-go"
+	"github.com/akavel/polyclip-go/polyutil"
+)
+
+var ops = map[string]polyclip.Op{
+	"union":        polyclip.UNION,
+	"intersection": polyclip.INTERSECTION,
+	"difference":   polyclip.DIFFERENCE,
+	"xor":          polyclip.XOR,
+}
+
+func main() {
+	var (
+		opName    = flag.String("op", "", "operation: union, intersection, difference or xor")
+		output    = flag.String("o", "", "output file (default: standard output)")
+		format    = flag.String("format", "", "output format: gpc, wkt, wkb, hexwkb, geojson, svg, binary or png")
+		validate  = flag.Bool("validate", false, "check inputs and the operation for problems")
+		simplify  = flag.Float64("simplify", 0, "tolerance of simplifying the result")
+		area      = flag.Bool("area", false, "report areas of inputs and result")
+		tolerance = flag.Float64("tolerance", 0.01, "maximum error of flattening curves in SVG inputs")
+		size      = flag.Int("size", 512, "size of PNG output, in pixels")
+	)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: polyclip [flags] subject [clipping]")
+		flag.PrintDefaults()
+	}
+	args := parseArgs(os.Args[1:])
+	if len(args) < 1 || len(args) > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	op, ok := ops[*opName]
+	switch {
+	case *opName != "" && !ok:
+		die(fmt.Errorf("unknown operation: %q", *opName))
+	case *opName != "" && len(args) != 2:
+		die(fmt.Errorf("-op needs two input files"))
+	case *opName == "" && len(args) != 1:
+		die(fmt.Errorf("two input files need -op"))
+	}
+
+	inputs := []polyclip.Polygon{}
+	inputFormat := ""
+	for _, path := range args {
+		p, f, err := load(path, *tolerance)
+		if err != nil {
+			die(err)
+		}
+		inputs = append(inputs, p)
+		if inputFormat == "" {
+			inputFormat = f
+		}
+	}
+
+	// reports go to the standard output, unless the result is written there
+	report := io.Writer(os.Stdout)
+	writeResult := *output != "" || (!*validate && !*area)
+	if writeResult && (*output == "" || *output == "-") {
+		report = os.Stderr
+	}
+
+	problems := 0
+	if *validate {
+		for i, p := range inputs {
+			for _, msg := range check(p) {
+				fmt.Fprintf(report, "%s: %s\n", args[i], msg)
+				problems++
+			}
+		}
+	}
+
+	result := inputs[0]
+	if *opName != "" {
+		if *validate {
+			var err error
+			result, err = inputs[0].ConstructChecked(op, inputs[1])
+			if err != nil {
+				fmt.Fprintf(report, "%s: %v\n", *opName, err)
+				problems++
+			}
+		} else {
+			result = inputs[0].Construct(op, inputs[1])
+		}
+	}
+	if *simplify > 0 {
+		result = polyutil.Simplify(result, *simplify)
+	}
+
+	if *area {
+		for i, p := range inputs {
+			fmt.Fprintf(report, "%s: area %g\n", args[i], polyutil.Area(p))
+		}
+		if *opName != "" || *simplify > 0 {
+			fmt.Fprintf(report, "result: area %g\n", polyutil.Area(result))
+		}
+	}
+
+	if writeResult {
+		if *format == "" {
+			*format = formatOf(*output)
+		}
+		if *format == "" {
+			*format = inputFormat
+		}
+		if err := save(*output, *format, result, inputs, *size); err != nil {
+			die(err)
+		}
+	}
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseArgs parses the command line flags, which may be interspersed with
+// the other arguments, and returns the latter.
+func parseArgs(args []string) []string {
+	var rest []string
+	for {
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			return rest
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// check returns descriptions of problems found in p, which may make the
+// results of operations on it unreliable.
+func check(p polyclip.Polygon) []string {
+	var problems []string
+	for i, c := range p {
+		if len(c) < 3 {
+			problems = append(problems, fmt.Sprintf("contour %d has only %d vertices", i, len(c)))
+		}
+		for j, pt := range c {
+			if math.IsNaN(pt.X) || math.IsNaN(pt.Y) || math.IsInf(pt.X, 0) || math.IsInf(pt.Y, 0) {
+				problems = append(problems, fmt.Sprintf("contour %d: vertex %d has non-finite coordinates %v", i, j, pt))
+			}
+			if j > 0 && pt.Equals(c[j-1]) {
+				problems = append(problems, fmt.Sprintf("contour %d: vertex %d repeats the previous one", i, j))
+			}
+		}
+	}
+	return problems
+}
+
+func load(path string, tolerance float64) (polyclip.Polygon, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	format, err := detect(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %v", path, err)
+	}
+	p, err := decode(data, format, tolerance)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %v", path, err)
+	}
+	return p, format, nil
+}
+
+func save(path, format string, result polyclip.Polygon, inputs []polyclip.Polygon, size int) error {
+	if path == "" || path == "-" {
+		return encode(os.Stdout, format, result, inputs, size)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = encode(f, format, result, inputs, size)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func die(err error) {
+	fmt.Fprintln(os.Stderr, "polyclip:", err)
+	os.Exit(1)
+}
@@ -30,11 +30,11 @@ type connector struct {
 	closedPolys []chain
 }
 
-func (c *connector) add(s segment) {
+func (c *connector) add(s segment, source EdgeSource) {
 	// j iterates through the openPolygon chains.
 	for j := range c.openPolys {
 		chain := &c.openPolys[j]
-		if !chain.linkSegment(s) {
+		if !chain.linkSegment(s, source) {
 			continue
 		}
 
@@ -44,6 +44,7 @@ func (c *connector) add(s segment) {
 				// a chain. (i.e. chain was <p0, p1>, we tried linking Segment(p1, p0)
 				// so the chain was closed illegally.
 				chain.closed = false
+				chain.sources = chain.sources[:len(chain.sources)-1]
 				return
 			}
 			// move the chain from openPolys to closedPolys
@@ -68,7 +69,29 @@ func (c *connector) add(s segment) {
 	}
 
 	// The segment cannot be connected with any open polygon
-	c.openPolys = append(c.openPolys, *newChain(s))
+	c.openPolys = append(c.openPolys, *newChain(s, source))
+}
+
+// openChains returns copies of the chains which could not be closed.
+func (c *connector) openChains() []Contour {
+	var chains []Contour
+	for _, chain := range c.openPolys {
+		if len(chain.points) == 0 {
+			continue
+		}
+		chains = append(chains, Contour(chain.points).Clone())
+	}
+	return chains
+}
+
+// sources returns the sources of edges of the closed chains, in the same
+// order as toPolygon returns them.
+func (c *connector) sources() [][]EdgeSource {
+	sources := [][]EdgeSource{}
+	for _, chain := range c.closedPolys {
+		sources = append(sources, append([]EdgeSource(nil), chain.sources...))
+	}
+	return sources
 }
 
 func (c *connector) toPolygon() Polygon {
@@ -82,3 +105,92 @@ func (c *connector) toPolygon() Polygon {
 	}
 	return poly
 }
+
+// areaConnector connects the segments of the result like connector, but only
+// keeps the ends of the chains, and the sums of the areas under their
+// segments, as returned by areaUnder. Only the closed chains count, like in
+// the polygon returned by connector.
+type areaConnector struct {
+	open []areaChain
+	area float64 // of the closed chains
+}
+
+type areaChain struct {
+	front, back Point
+	segments    int
+	area        float64
+}
+
+func (c *areaConnector) add(s segment, area float64) {
+	for j := range c.open {
+		chain := &c.open[j]
+		linked, closed := chain.linkSegment(s)
+		if !linked {
+			continue
+		}
+
+		if closed {
+			if chain.segments == 1 {
+				// the same segment flipped, see connector.add
+				return
+			}
+			c.area += chain.area + area
+			c.open = append(c.open[:j], c.open[j+1:]...)
+			return
+		}
+		chain.segments++
+		chain.area += area
+
+		for i := j + 1; i < len(c.open); i++ {
+			if chain.linkChain(&c.open[i]) {
+				c.open = append(c.open[:i], c.open[i+1:]...)
+				return
+			}
+		}
+		return
+	}
+
+	c.open = append(c.open, areaChain{front: s.start, back: s.end, segments: 1, area: area})
+}
+
+// linkSegment moves an end of the chain to the other end of s, if s starts
+// or ends at it, like chain.linkSegment. If the other end of s is the other
+// end of the chain, the chain is closed instead.
+func (c *areaChain) linkSegment(s segment) (linked, closed bool) {
+	for _, s := range []segment{s, {s.end, s.start}} {
+		switch {
+		case s.start.Equals(c.front):
+			if s.end.Equals(c.back) {
+				return true, true
+			}
+			c.front = s.end
+			return true, false
+		case s.end.Equals(c.back):
+			if s.start.Equals(c.front) {
+				return true, true
+			}
+			c.back = s.start
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// linkChain joins other to the chain, like chain.linkChain.
+func (c *areaChain) linkChain(other *areaChain) bool {
+	switch {
+	case other.front.Equals(c.back):
+		c.back = other.back
+	case other.back.Equals(c.front):
+		c.front = other.front
+	case other.front.Equals(c.front):
+		c.front = other.back
+	case other.back.Equals(c.back):
+		c.back = other.front
+	default:
+		return false
+	}
+	c.segments += other.segments
+	c.area += other.area
+	return true
+}
@@ -54,7 +54,7 @@ func TestConnectorAddClosing1(t *T) {
 	}
 
 	for i, x := range cases {
-		x.c.add(x.add)
+		x.c.add(x.add, EdgeSource{})
 		verify(t, len(x.c.openPolys[0].points) == x.length, "Case %d, expected len(openPolys[0])==%d, got: %v", i, x.length, x.c)
 	}
 
@@ -25,6 +25,7 @@ package polyclip
 
 import (
 	"fmt"
+	"math/big"
 )
 
 // A container for endpoint data. A endpoint represents a location of interest (vertex between two polygon edges)
@@ -40,6 +41,12 @@ type endpoint struct {
 	inout bool
 	edgeType
 	inside bool // Only used in "left" events. Is the segment (p, other->p) inside the other polygon?
+
+	source EdgeSource // edge of an input polygon the segment was cut from
+
+	// Only used in "left" events by Arrangement: the sorted indices of input
+	// polygons covering the regions just below and above the segment.
+	coverBelow, coverAbove []int
 }
 
 func (e endpoint) String() string {
@@ -62,9 +69,47 @@ func (se *endpoint) segment() segment {
 	return segment{se.p, se.other.p}
 }
 
+// ccwErrBound bounds the relative error of the floating point determinant in
+// signedArea; see J. R. Shewchuk, "Adaptive Precision Floating-Point
+// Arithmetic and Fast Robust Geometric Predicates".
+const ccwErrBound = (3 + 16*epsilon) * epsilon
+
+const epsilon = 1.0 / (1 << 53)
+
+// signedArea returns twice the signed area of the triangle p0, p1, p2:
+// positive if the points are in counterclockwise order, negative if
+// clockwise, and zero if they are collinear. The sign is always exact; when
+// the floating point result is too close to zero to be trusted, it's
+// recomputed in exact arithmetic.
 func signedArea(p0, p1, p2 Point) float64 {
-	return (p0.X-p2.X)*(p1.Y-p2.Y) -
-		(p1.X-p2.X)*(p0.Y-p2.Y)
+	left := (p0.X - p2.X) * (p1.Y - p2.Y)
+	right := (p1.X - p2.X) * (p0.Y - p2.Y)
+	det := left - right
+	var sum float64
+	switch {
+	case left > 0 && right > 0:
+		sum = left + right
+	case left < 0 && right < 0:
+		sum = -left - right
+	default:
+		// the products have different signs, or one of them is zero
+		return det
+	}
+	if det > ccwErrBound*sum || -det > ccwErrBound*sum {
+		return det
+	}
+	return exactSignedArea(p0, p1, p2)
+}
+
+func exactSignedArea(p0, p1, p2 Point) float64 {
+	r := func(f float64) *big.Rat { return new(big.Rat).SetFloat64(f) }
+	x0, y0 := r(p0.X), r(p0.Y)
+	x1, y1 := r(p1.X), r(p1.Y)
+	x2, y2 := r(p2.X), r(p2.Y)
+	left := new(big.Rat).Mul(x0.Sub(x0, x2), new(big.Rat).Sub(y1, y2))
+	right := new(big.Rat).Mul(x1.Sub(x1, x2), y0.Sub(y0, y2))
+	f, _ := left.Sub(left, right).Float64()
+	return f
 }
 
 // Checks if this sweep event is below point p.
@@ -39,3 +39,24 @@ func TestAbove(t *T) {
 		verify(t, e.above(v.x) == v.result, "Expected %v above %v (case %d/b)", e, v.x, i)
 	}
 }
+
+func TestSignedArea(t *T) {
+	// consecutive Fibonacci numbers: F46*F44 - F45*F45 = -1, while both
+	// products are far beyond the exact range of float64
+	f46, f45, f44 := 1836311903.0, 1134903170.0, 701408733.0
+	cases := []struct {
+		p0, p1, p2 Point
+		sign       float64
+	}{
+		{Point{0, 0}, Point{1, 0}, Point{0, 1}, 1},
+		{Point{0, 0}, Point{0, 1}, Point{1, 0}, -1},
+		{Point{f46, f45}, Point{f45, f44}, Point{0, 0}, -1},
+		{Point{f45, f44}, Point{f46, f45}, Point{0, 0}, 1},
+		{Point{f46 + 3, f45 + 5}, Point{f45 + 3, f44 + 5}, Point{3, 5}, -1},
+		{Point{1, 1}, Point{3, 3}, Point{7, 7}, 0},
+	}
+	for i, c := range cases {
+		a := signedArea(c.p0, c.p1, c.p2)
+		verify(t, (a > 0) == (c.sign > 0) && (a < 0) == (c.sign < 0), "Case %d: expected sign %v, got %v", i, c.sign, a)
+	}
+}
@@ -78,6 +78,28 @@ func endpointLess(e1, e2 *endpoint) bool {
 	return e1.above(e2.other.p)
 }
 
+// merge adds events, which must be sorted already like the elements of a
+// sorted queue, in linear time after sorting the queue itself.
+func (q *eventQueue) merge(events []*endpoint) {
+	if !q.sorted {
+		sort.Sort(queueComparer(q.elements))
+		q.sorted = true
+	}
+	a, b := q.elements, events
+	merged := make([]*endpoint, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if endpointLess(b[0], a[0]) {
+			merged = append(merged, b[0])
+			b = b[1:]
+		} else {
+			merged = append(merged, a[0])
+			a = a[1:]
+		}
+	}
+	merged = append(merged, a...)
+	q.elements = append(merged, b...)
+}
+
 func (q *eventQueue) dequeue() *endpoint {
 	if !q.sorted {
 		sort.Sort(queueComparer(q.elements))
@@ -0,0 +1,235 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// evenOddArea calculates the area of the region covered by an odd number of
+// contours of p, independently of the algorithm being tested. The plane is cut
+// into vertical slabs at all vertices and edge intersections; inside a slab
+// no edges cross, so the covered length of a vertical line is linear in x and
+// its value in the middle of the slab gives the exact area of the slab.
+func evenOddArea(p polyclip.Polygon) float64 {
+	type edge struct{ a, b polyclip.Point }
+	edges := []edge{}
+	xs := []float64{}
+	for _, c := range p {
+		for i := range c {
+			a, b := c[i], c[(i+1)%len(c)]
+			if a.X > b.X {
+				a, b = b, a
+			}
+			edges = append(edges, edge{a, b})
+			xs = append(xs, a.X, b.X)
+		}
+	}
+	for i := range edges {
+		for j := i + 1; j < len(edges); j++ {
+			e, f := edges[i], edges[j]
+			d := (e.b.X-e.a.X)*(f.b.Y-f.a.Y) - (e.b.Y-e.a.Y)*(f.b.X-f.a.X)
+			if d == 0 {
+				continue
+			}
+			s := ((f.a.X-e.a.X)*(f.b.Y-f.a.Y) - (f.a.Y-e.a.Y)*(f.b.X-f.a.X)) / d
+			if s > 0 && s < 1 {
+				xs = append(xs, e.a.X+s*(e.b.X-e.a.X))
+			}
+		}
+	}
+	sort.Float64s(xs)
+
+	area := 0.0
+	for i := 1; i < len(xs); i++ {
+		x0, x1 := xs[i-1], xs[i]
+		if x1 <= x0 {
+			continue
+		}
+		x := (x0 + x1) / 2
+		ys := []float64{}
+		for _, e := range edges {
+			if e.a.X <= x && x < e.b.X {
+				ys = append(ys, e.a.Y+(x-e.a.X)*(e.b.Y-e.a.Y)/(e.b.X-e.a.X))
+			}
+		}
+		sort.Float64s(ys)
+		for j := 1; j < len(ys); j += 2 {
+			area += (ys[j] - ys[j-1]) * (x1 - x0)
+		}
+	}
+	return area
+}
+
+// gridPolygons decodes fuzzer input into a pair of polygons with vertices on
+// a small 16x16 grid, so that collinear edges and shared vertices are common.
+// Each contour starts with a header byte:
+//   - bits 0-2: number of vertices, minus 3;
+//   - bit 7: the contour belongs to the clipping polygon instead of the subject;
+//   - bit 6: the contour is also added to the other polygon, giving duplicate edges;
+//   - bit 5: the vertices are moved by a tiny amount, giving nearly collinear edges.
+//
+// Each vertex takes a single byte, with x in the high and y in the low nibble.
+// Contours with zero area are skipped.
+func gridPolygons(data []byte) (subject, clipping polyclip.Polygon) {
+	for len(data) > 0 {
+		hdr := data[0]
+		data = data[1:]
+		n := int(hdr&7) + 3
+		if len(data) < n {
+			break
+		}
+		c := polyclip.Contour{}
+		for _, b := range data[:n] {
+			p := polyclip.Point{X: float64(b >> 4), Y: float64(b & 0xf)}
+			if hdr&0x20 != 0 {
+				p.X += float64(b&3) * 1e-9
+				p.Y -= float64(b>>2&3) * 1e-9
+			}
+			c.Add(p)
+		}
+		data = data[n:]
+		if evenOddArea(polyclip.Polygon{c}) == 0 {
+			continue // skip fully degenerate contours
+		}
+
+		if hdr&0x80 == 0 {
+			subject.Add(c)
+		} else {
+			clipping.Add(c)
+		}
+		if hdr&0x40 != 0 {
+			if hdr&0x80 == 0 {
+				clipping.Add(c.Clone())
+			} else {
+				subject.Add(c.Clone())
+			}
+		}
+	}
+	return
+}
+
+// floatPolygons decodes fuzzer input into a pair of polygons with arbitrary
+// coordinates. The first byte holds the number of vertices of the first
+// contour of the subject, minus 3; the remaining bytes are read as pairs of
+// float32 coordinates, forming two contours: one of the subject and one of
+// the clipping polygon.
+func floatPolygons(data []byte) (subject, clipping polyclip.Polygon) {
+	if len(data) < 1 {
+		return
+	}
+	n := int(data[0]%16) + 3
+	data = data[1:]
+	c := polyclip.Contour{}
+	for len(data) >= 8 {
+		x := math.Float32frombits(binary.LittleEndian.Uint32(data))
+		y := math.Float32frombits(binary.LittleEndian.Uint32(data[4:]))
+		data = data[8:]
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) || math.Abs(float64(x)) > 1e6 ||
+			math.IsNaN(float64(y)) || math.IsInf(float64(y), 0) || math.Abs(float64(y)) > 1e6 {
+			continue
+		}
+		c.Add(polyclip.Point{X: float64(x), Y: float64(y)})
+		if len(c) == n && len(subject) == 0 {
+			subject.Add(c)
+			c = polyclip.Contour{}
+		}
+	}
+	if len(c) >= 3 {
+		clipping.Add(c)
+	}
+	return
+}
+
+// checkProperties verifies algebraic properties which should hold for results
+// of Construct on any pair of polygons.
+func checkProperties(t *T, a, b polyclip.Polygon) {
+	if len(a) == 0 || len(b) == 0 {
+		return
+	}
+	results := map[polyclip.Op]polyclip.Polygon{}
+	for _, op := range []polyclip.Op{polyclip.UNION, polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.XOR} {
+		r := a.ConstructResult(op, b)
+		if r.Err() != nil {
+			t.Fatalf("op %d: %v: %v\nsubject:  %v\nclipping: %v", op, r.Err(), r.OpenChains, a, b)
+		}
+		results[op] = r.Polygon
+	}
+	union, inter, diff, xor := results[polyclip.UNION], results[polyclip.INTERSECTION],
+		results[polyclip.DIFFERENCE], results[polyclip.XOR]
+
+	areaA, areaB := evenOddArea(a), evenOddArea(b)
+	eps := 1e-6 * (1 + areaA + areaB)
+	same := func(f, g float64) bool { return math.Abs(f-g) <= eps }
+	fail := func(format string, args ...interface{}) {
+		t.Fatalf(format+"\nsubject:  %v\nclipping: %v", append(args, a, b)...)
+	}
+
+	if u, i := evenOddArea(union), evenOddArea(inter); !same(u+i, areaA+areaB) {
+		fail("area(A∪B)+area(A∩B) = %v+%v, expected area(A)+area(B) = %v+%v", u, i, areaA, areaB)
+	}
+	if d, i := evenOddArea(diff), evenOddArea(inter); !same(d+i, areaA) {
+		fail("area(A−B)+area(A∩B) = %v+%v, expected area(A) = %v", d, i, areaA)
+	}
+	if overlap := evenOddArea(diff.Construct(polyclip.INTERSECTION, inter)); !same(overlap, 0) {
+		fail("area((A−B)∩(A∩B)) = %v, expected 0", overlap)
+	}
+	if x, ui := evenOddArea(xor), evenOddArea(union.Construct(polyclip.DIFFERENCE, inter)); !same(x, ui) {
+		fail("area(A⊕B) = %v, expected area((A∪B)−(A∩B)) = %v", x, ui)
+	}
+}
+
+// FuzzConstruct checks properties of results of Construct on polygons with
+// vertices on a small grid (see gridPolygons). When run with -fuzz, failing
+// inputs are saved in testdata/fuzz/FuzzConstruct, and replayed from there on
+// every subsequent "go test".
+func FuzzConstruct(f *F) {
+	f.Add([]byte{0x01, 0x11, 0x13, 0x33, 0x31, 0x81, 0x22, 0x24, 0x44, 0x42})                         // two overlapping squares
+	f.Add([]byte{0x00, 0x00, 0x80, 0x08, 0x80, 0x33, 0x3c, 0xc3})                                     // two triangles
+	f.Add([]byte{0x01, 0x11, 0x15, 0x55, 0x51, 0x01, 0x22, 0x23, 0x33, 0x32, 0x80, 0x30, 0x3f, 0xf0}) // square with a hole
+	f.Fuzz(func(t *T, data []byte) {
+		a, b := gridPolygons(data)
+		checkProperties(t, a, b)
+	})
+}
+
+// FuzzConstructFloat checks properties of results of Construct on polygons
+// with arbitrary coordinates (see floatPolygons).
+func FuzzConstructFloat(f *F) {
+	square := func(x, y, size float32) []byte {
+		b := []byte{}
+		for _, p := range [][2]float32{{x, y}, {x, y + size}, {x + size, y + size}, {x + size, y}} {
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p[0]))
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(p[1]))
+		}
+		return b
+	}
+	f.Add(append(append([]byte{1}, square(0, 0, 2)...), square(1, 1, 2)...))
+	f.Add(append(append([]byte{1}, square(-1.5, 0.25, 10)...), square(0.5, -3, 1.25)...))
+	f.Fuzz(func(t *T, data []byte) {
+		a, b := floatPolygons(data)
+		checkProperties(t, a, b)
+	})
+}
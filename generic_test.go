@@ -0,0 +1,166 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+type meters float64
+
+func convert[D, C polyclip.Coord](p polyclip.PolygonOf[C]) polyclip.PolygonOf[D] {
+	r := polyclip.PolygonOf[D]{}
+	for _, c := range p {
+		d := polyclip.ContourOf[D]{}
+		for _, pt := range c {
+			d.Add(polyclip.PointOf[D]{X: D(pt.X), Y: D(pt.Y)})
+		}
+		r.Add(d)
+	}
+	return r
+}
+
+// constructAs performs the operation in coordinates of type C.
+func constructAs[C polyclip.Coord](subject polyclip.Polygon, op polyclip.Op, clipping polyclip.Polygon) polyclip.Polygon {
+	result := convert[C](subject).Construct(op, convert[C](clipping))
+	return convert[float64](result)
+}
+
+func TestConstructGeneric(t *T) {
+	square := func(x, y, size float64) polyclip.Polygon {
+		return polyclip.Polygon{{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}}
+	}
+	cases := []struct {
+		subject, clipping polyclip.Polygon
+		skip              string // types which can't represent the polygons
+	}{
+		{square(0, 0, 4), square(2, 2, 4), ""},
+		{square(0, 0, 4), square(1, 1, 2), ""},
+		{square(0, 0, 4), polyclip.Polygon{{{0, 4}, {2, -4}, {4, 4}}}, ""},
+		{square(0.5, 0.5, 4), polyclip.Polygon{{{0.5, 4.5}, {2.5, -3.5}, {4.5, 4.5}}}, "int32 int64"},
+		{square(1<<30, 1<<30, 4), square(1<<30+2, 1<<30-2, 4), "float32"},
+	}
+	for i, c := range cases {
+		for _, op := range []polyclip.Op{polyclip.UNION, polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.XOR} {
+			expected := dump(normalize(c.subject.Construct(op, c.clipping)))
+			for _, result := range []struct {
+				name string
+				poly polyclip.Polygon
+			}{
+				{"float32", constructAs[float32](c.subject, op, c.clipping)},
+				{"meters", constructAs[meters](c.subject, op, c.clipping)},
+				{"int32", constructAs[int32](c.subject, op, c.clipping)},
+				{"int64", constructAs[int64](c.subject, op, c.clipping)},
+			} {
+				if strings.Contains(c.skip, result.name) {
+					continue
+				}
+				if got := dump(normalize(result.poly)); got != expected {
+					t.Errorf("Case %d, op %v, %s: expected %s, got %s", i, op, result.name, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestConstructRounding(t *T) {
+	subject := polyclip.PolygonOf[int32]{{{0, 0}, {4, 0}, {4, 4}, {0, 4}}}
+	clipping := polyclip.PolygonOf[int32]{{{-1, 0}, {8, 3}, {-1, 3}}}
+	result := subject.Construct(polyclip.INTERSECTION, clipping)
+	expected := dump(normalize(polyclip.Polygon{{{0, 0}, {4, 2}, {4, 3}, {0, 3}}}))
+	if got := dump(normalize(convert[float64](result))); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestGenericHelpers(t *T) {
+	c := polyclip.ContourOf[int64]{{1 << 40, 0}, {1<<40 + 4, 0}, {1<<40 + 4, 4}, {1 << 40, 4}}
+	if !c.Contains(polyclip.PointOf[int64]{X: 1<<40 + 1, Y: 1}) || c.Contains(polyclip.PointOf[int64]{X: 1<<40 - 1, Y: 1}) {
+		t.Errorf("Wrong Contains results for %v", c)
+	}
+	bb := polyclip.PolygonOf[int64]{{}, c}.BoundingBox()
+	if bb != (polyclip.RectangleOf[int64]{Min: c[0], Max: c[2]}) {
+		t.Errorf("Expected bounding box of %v, got %v", c, bb)
+	}
+	if a := (polyclip.RectangleOf[int64]{Max: polyclip.PointOf[int64]{X: 1<<60 + 4, Y: 4}, Min: polyclip.PointOf[int64]{X: 1 << 60}}).Area(); a != 16 {
+		t.Errorf("Expected area 16, got %v", a)
+	}
+}
+
+func TestMarshalGeneric(t *T) {
+	big := polyclip.PointOf[int64]{X: 1<<60 + 1, Y: -3}
+	b, err := json.Marshal(big)
+	if err != nil || string(b) != "[1152921504606846977,-3]" {
+		t.Errorf("Expected exact int64 encoding, got: %s %v", b, err)
+	}
+	var p polyclip.PointOf[int64]
+	if err := json.Unmarshal(b, &p); err != nil || p != big {
+		t.Errorf("Expected %v, got: %v %v", big, p, err)
+	}
+	b, err = json.Marshal(polyclip.ContourOf[float32]{{X: 0.1, Y: 2}})
+	if err != nil || string(b) != "[[0.1,2]]" {
+		t.Errorf("Expected shortest float32 encoding, got: %s %v", b, err)
+	}
+	var m polyclip.PolygonOf[meters]
+	if err := m.UnmarshalText([]byte("(0 0, 1.5 0, 1 1)")); err != nil || fmt.Sprint(m) != "[[{0 0} {1.5 0} {1 1}]]" {
+		t.Errorf("Expected polygon, got: %v %v", m, err)
+	}
+
+	var q polyclip.PointOf[int32]
+	for _, input := range []string{`[1.5,2]`, `[3000000000,1]`, `["1",2]`} {
+		if err := json.Unmarshal([]byte(input), &q); err == nil {
+			t.Errorf("Expected error for %s, got %v", input, q)
+		}
+	}
+}
+
+func TestConstructResultGeneric(t *T) {
+	subject := polyclip.PolygonOf[int64]{{{1 << 44, 0}, {1<<44 + 4, 0}, {1<<44 + 4, 4}, {1 << 44, 4}}}
+	clipping := polyclip.PolygonOf[int64]{{{1<<44 + 2, 2}, {1<<44 + 6, 2}, {1<<44 + 6, 6}, {1<<44 + 2, 6}}}
+	r := subject.ConstructResult(polyclip.INTERSECTION, clipping)
+	expected := polyclip.PolygonOf[int64]{{{1<<44 + 2, 2}, {1<<44 + 4, 2}, {1<<44 + 4, 4}, {1<<44 + 2, 4}}}
+	if r.Err() != nil || dump(normalize(convert[float64](r.Close(polyclip.JoinNearest)))) != dump(normalize(convert[float64](expected))) {
+		t.Errorf("Expected %v, got: %v %v", expected, r.Polygon, r.Err())
+	}
+}
+
+func TestConstructRange(t *T) {
+	square := func(x int64) polyclip.PolygonOf[int64] {
+		return polyclip.PolygonOf[int64]{{{x, 0}, {x + 4, 0}, {x + 4, 4}, {x, 4}}}
+	}
+	if r := square(1<<45-5).Construct(polyclip.UNION, square(-1<<45+1)); len(r) != 2 {
+		t.Errorf("Expected 2 contours, got: %v", r)
+	}
+	for _, x := range []int64{1<<45 - 4, -1 << 45, 1 << 62} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic for coordinates %d", x)
+				}
+			}()
+			square(x).Construct(polyclip.UNION, square(0))
+		}()
+	}
+}
@@ -26,65 +26,161 @@
 package polyclip
 
 import (
+	"fmt"
 	"math"
 )
 
-type Point struct {
-	X, Y float64
+// Coord is the set of types which can be used for coordinates of the
+// geometric types. Computations are done in float64, which represents all
+// float32 and int32 values, and int64 values up to 2^53, exactly. The
+// predicates deciding on which side of an edge a point lies are exact, so
+// results for integer coordinates aren't affected by rounding of the
+// intermediate products; only the points created where edges cross are
+// rounded to the nearest integer. The operations on polygons panic if an
+// int64 coordinate isn't less than 2^45 in magnitude: crossing points are
+// snapped to vertices closer than 1e-14 of their coordinates, which would
+// merge distinct integer points above that.
+type Coord interface {
+	~float32 | ~float64 | ~int32 | ~int64
 }
 
+// maxInt is the bound on the magnitude of integer coordinates, see Coord.
+const maxInt = 1 << 45
+
+// isInteger returns whether T is one of the integer types of Coord.
+func isInteger[T Coord]() bool {
+	return T(1)/2 == 0
+}
+
+// toCoord converts f to T, rounding it to the nearest integer if T is an
+// integer type.
+func toCoord[T Coord](f float64) T {
+	if isInteger[T]() {
+		f = math.Round(f)
+	}
+	return T(f)
+}
+
+// PointOf is a point with coordinates of type T.
+type PointOf[T Coord] struct {
+	X, Y T
+}
+
+// Point is a point with float64 coordinates.
+type Point = PointOf[float64]
+
 // Equals returns true if both p1 and p2 describe exactly the same point.
-func (p1 Point) Equals(p2 Point) bool {
+func (p1 PointOf[T]) Equals(p2 PointOf[T]) bool {
 	return p1.X == p2.X && p1.Y == p2.Y
 }
 
 // Length returns distance from p to point (0, 0).
-func (p Point) Length() float64 {
-	return math.Sqrt(p.X*p.X + p.Y*p.Y)
+func (p PointOf[T]) Length() float64 {
+	x, y := float64(p.X), float64(p.Y)
+	return math.Sqrt(x*x + y*y)
 }
 
-type Rectangle struct {
-	Min, Max Point
+func (p PointOf[T]) float() Point {
+	return Point{float64(p.X), float64(p.Y)}
 }
 
-func (r1 Rectangle) union(r2 Rectangle) Rectangle {
-	return Rectangle{
-		Min: Point{
-			X: math.Min(r1.Min.X, r2.Min.X),
-			Y: math.Min(r1.Min.Y, r2.Min.Y),
+type RectangleOf[T Coord] struct {
+	Min, Max PointOf[T]
+}
+
+// Rectangle is a rectangle with float64 coordinates.
+type Rectangle = RectangleOf[float64]
+
+func (r1 RectangleOf[T]) union(r2 RectangleOf[T]) RectangleOf[T] {
+	return RectangleOf[T]{
+		Min: PointOf[T]{
+			X: min(r1.Min.X, r2.Min.X),
+			Y: min(r1.Min.Y, r2.Min.Y),
 		},
-		Max: Point{
-			X: math.Max(r1.Max.X, r2.Max.X),
-			Y: math.Max(r1.Max.Y, r2.Max.Y),
+		Max: PointOf[T]{
+			X: max(r1.Max.X, r2.Max.X),
+			Y: max(r1.Max.Y, r2.Max.Y),
 		}}
 }
 
 // Overlaps returns whether r1 and r2 have a non-empty intersection.
-func (r1 Rectangle) Overlaps(r2 Rectangle) bool {
+func (r1 RectangleOf[T]) Overlaps(r2 RectangleOf[T]) bool {
 	return r1.Min.X <= r2.Max.X && r1.Max.X >= r2.Min.X &&
 		r1.Min.Y <= r2.Max.Y && r1.Max.Y >= r2.Min.Y
 }
 
+// Area returns the area of r.
+func (r RectangleOf[T]) Area() float64 {
+	return span(r.Min.X, r.Max.X) * span(r.Min.Y, r.Max.Y)
+}
+
+// span returns b-a, without losing precision on large integer coordinates.
+func span[T Coord](a, b T) float64 {
+	if isInteger[T]() {
+		return float64(int64(b) - int64(a))
+	}
+	return float64(b) - float64(a)
+}
+
+// Intersect returns the largest rectangle contained by both r1 and r2. If
+// they don't overlap, the zero rectangle is returned.
+func (r1 RectangleOf[T]) Intersect(r2 RectangleOf[T]) RectangleOf[T] {
+	if !r1.Overlaps(r2) {
+		return RectangleOf[T]{}
+	}
+	return RectangleOf[T]{
+		Min: PointOf[T]{
+			X: max(r1.Min.X, r2.Min.X),
+			Y: max(r1.Min.Y, r2.Min.Y),
+		},
+		Max: PointOf[T]{
+			X: min(r1.Max.X, r2.Max.X),
+			Y: min(r1.Max.Y, r2.Max.Y),
+		}}
+}
+
+// Contains returns whether r2 lies entirely inside r1 (possibly touching
+// its boundary).
+func (r1 RectangleOf[T]) Contains(r2 RectangleOf[T]) bool {
+	return r1.Min.X <= r2.Min.X && r2.Max.X <= r1.Max.X &&
+		r1.Min.Y <= r2.Min.Y && r2.Max.Y <= r1.Max.Y
+}
+
+// Expand returns the smallest rectangle containing both r1 and r2.
+func (r1 RectangleOf[T]) Expand(r2 RectangleOf[T]) RectangleOf[T] {
+	return r1.union(r2)
+}
+
 // Used to represent an edge of a polygon.
 type segment struct {
 	start, end Point
 }
 
-// Contour represents a sequence of vertices connected by line segments, forming a closed shape.
-type Contour []Point
+// ContourOf represents a sequence of vertices connected by line segments, forming a closed shape.
+type ContourOf[T Coord] []PointOf[T]
+
+// Contour is a contour with float64 coordinates.
+type Contour = ContourOf[float64]
 
 // Add is a convenience method for appending a point to a contour.
-func (c *Contour) Add(p Point) {
+func (c *ContourOf[T]) Add(p PointOf[T]) {
 	*c = append(*c, p)
 }
 
 // BoundingBox finds minimum and maximum coordinates of points in a contour.
-func (c Contour) BoundingBox() Rectangle {
-	bb := Rectangle{}
-	bb.Min.X = math.Inf(1)
-	bb.Min.Y = math.Inf(1)
-	bb.Max.X = math.Inf(-1)
-	bb.Max.Y = math.Inf(-1)
+// The bounding box of an empty contour is the zero rectangle for integer
+// coordinates, and a rectangle from +Inf to -Inf for floating point ones.
+func (c ContourOf[T]) BoundingBox() RectangleOf[T] {
+	bb := RectangleOf[T]{}
+	if len(c) > 0 {
+		bb.Min, bb.Max = c[0], c[0]
+	} else if !isInteger[T]() {
+		inf := math.Inf(1)
+		bb.Min.X = T(inf)
+		bb.Min.Y = T(inf)
+		bb.Max.X = T(-inf)
+		bb.Max.Y = T(-inf)
+	}
 
 	for _, p := range c {
 		if p.X > bb.Max.X {
@@ -103,11 +199,11 @@ func (c Contour) BoundingBox() Rectangle {
 	return bb
 }
 
-func (c Contour) segment(index int) segment {
+func (c ContourOf[T]) segment(index int) segment {
 	if index == len(c)-1 {
-		return segment{c[len(c)-1], c[0]}
+		return segment{c[len(c)-1].float(), c[0].float()}
 	}
-	return segment{c[index], c[index+1]}
+	return segment{c[index].float(), c[index+1].float()}
 	// if out-of-bounds, we expect panic detected by runtime
 }
 
@@ -116,7 +212,7 @@ func (c Contour) segment(index int) segment {
 // convex or concave.
 // See: http://en.wikipedia.org/wiki/Point_in_polygon#Ray_casting_algorithm
 // Returns true if p is inside the polygon defined by contour.
-func (c Contour) Contains(p Point) bool {
+func (c ContourOf[T]) Contains(p PointOf[T]) bool {
 	// Cast ray from p.x towards the right
 	intersections := 0
 	for i := range c {
@@ -140,14 +236,14 @@ func (c Contour) Contains(p Point) bool {
 		}
 		// Edge is from curr to next.
 
-		if p.X >= math.Max(curr.X, next.X) ||
+		if p.X >= max(curr.X, next.X) ||
 			next.Y == curr.Y {
 			continue
 		}
 
-		// Find where the line intersects...
-		xint := (p.Y-curr.Y)*(next.X-curr.X)/(next.Y-curr.Y) + curr.X
-		if curr.X != next.X && p.X > xint {
+		// Is the point right of where the edge crosses the ray? (i.e.
+		// on the right side of the edge going upwards)
+		if curr.X != next.X && signedArea(bottom.float(), top.float(), p.float()) < 0 {
 			continue
 		}
 
@@ -158,16 +254,19 @@ func (c Contour) Contains(p Point) bool {
 }
 
 // Clone returns a copy of a contour.
-func (c Contour) Clone() Contour {
-	return append([]Point{}, c...)
+func (c ContourOf[T]) Clone() ContourOf[T] {
+	return append([]PointOf[T]{}, c...)
 }
 
-// Polygon is carved out of a 2D plane by a set of (possibly disjoint) contours.
+// PolygonOf is carved out of a 2D plane by a set of (possibly disjoint) contours.
 // It can thus contain holes, and can be self-intersecting.
-type Polygon []Contour
+type PolygonOf[T Coord] []ContourOf[T]
+
+// Polygon is a polygon with float64 coordinates.
+type Polygon = PolygonOf[float64]
 
 // NumVertices returns total number of all vertices of all contours of a polygon.
-func (p Polygon) NumVertices() int {
+func (p PolygonOf[T]) NumVertices() int {
 	num := 0
 	for _, c := range p {
 		num += len(c)
@@ -176,29 +275,78 @@ func (p Polygon) NumVertices() int {
 }
 
 // BoundingBox finds minimum and maximum coordinates of points in a polygon.
-func (p Polygon) BoundingBox() Rectangle {
-	bb := p[0].BoundingBox()
+// Empty contours are skipped.
+func (p PolygonOf[T]) BoundingBox() RectangleOf[T] {
+	bb, empty := p[0].BoundingBox(), len(p[0]) == 0
 	for _, c := range p[1:] {
-		bb = bb.union(c.BoundingBox())
+		switch {
+		case len(c) == 0:
+		case empty:
+			bb, empty = c.BoundingBox(), false
+		default:
+			bb = bb.union(c.BoundingBox())
+		}
 	}
 
 	return bb
 }
 
 // Add is a convenience method for appending a contour to a polygon.
-func (p *Polygon) Add(c Contour) {
+func (p *PolygonOf[T]) Add(c ContourOf[T]) {
 	*p = append(*p, c)
 }
 
 // Clone returns a duplicate of a polygon.
-func (p Polygon) Clone() Polygon {
-	r := Polygon(make([]Contour, len(p)))
+func (p PolygonOf[T]) Clone() PolygonOf[T] {
+	r := PolygonOf[T](make([]ContourOf[T], len(p)))
 	for i := range p {
 		r[i] = p[i].Clone()
 	}
 	return r
 }
 
+// float returns p converted to float64 coordinates, which the algorithms
+// work with. It panics if an integer coordinate can't be converted exactly.
+func (p PolygonOf[T]) float() Polygon {
+	if f, ok := any(p).(Polygon); ok {
+		return f
+	}
+	r := make(Polygon, len(p))
+	for i, c := range p {
+		r[i] = make(Contour, len(c))
+		for j, pt := range c {
+			if isInteger[T]() && (exceeds(int64(pt.X)) || exceeds(int64(pt.Y))) {
+				panic(fmt.Sprintf("polyclip: coordinates of %v out of the supported range (-2^45, 2^45)", pt))
+			}
+			r[i][j] = pt.float()
+		}
+	}
+	return r
+}
+
+func exceeds(v int64) bool {
+	return v >= maxInt || v <= -maxInt
+}
+
+// polygonOf converts a result of the algorithms back to coordinates of type
+// T, point by point.
+func polygonOf[T Coord](p Polygon) PolygonOf[T] {
+	if r, ok := any(p).(PolygonOf[T]); ok {
+		return r
+	}
+	if p == nil {
+		return nil
+	}
+	r := make(PolygonOf[T], len(p))
+	for i, c := range p {
+		r[i] = make(ContourOf[T], len(c))
+		for j, pt := range c {
+			r[i][j] = PointOf[T]{toCoord[T](pt.X), toCoord[T](pt.Y)}
+		}
+	}
+	return r
+}
+
 // Op describes an operation which can be performed on two polygons.
 type Op int
 
@@ -217,10 +365,13 @@ const (
 // The paper describes the algorithm as performing in time O((n+k) log n),
 // where n is number of all edges of all polygons in operation, and
 // k is number of intersections of all polygon edges.
-func (p Polygon) Construct(operation Op, clipping Polygon) Polygon {
+//
+// With integer coordinates, points where edges cross are rounded to the
+// nearest integer, so neighbouring points of the result may coincide.
+func (p PolygonOf[T]) Construct(operation Op, clipping PolygonOf[T]) PolygonOf[T] {
 	c := clipper{
-		subject:  p,
-		clipping: clipping,
+		subject:  p.float(),
+		clipping: clipping.float(),
 	}
-	return c.compute(operation)
+	return polygonOf[T](c.compute(operation))
 }
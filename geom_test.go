@@ -82,6 +82,31 @@ func TestRectangleIntersects(t *T) {
 	}
 }
 
+func TestRectangleHelpers(t *T) {
+	r1 := rect(0, 0, 10, 20)
+	verify(t, r1.Area() == 200, "Expected area 200, got: %v", r1.Area())
+	verify(t, rect(5, 5, 0, 10).Area() == 0, "Expected zero area of degenerate rectangle")
+
+	cases := []struct{ a, b, intersection Rectangle }{
+		{r1, rect(5, 10, 10, 20), rect(5, 10, 5, 10)},
+		{r1, rect(2, 2, 2, 2), rect(2, 2, 2, 2)},
+		{r1, rect(10, 20, 5, 5), rect(10, 20, 0, 0)},
+		{r1, rect(11, 0, 5, 5), Rectangle{}},
+	}
+	for i, v := range cases {
+		r := v.a.Intersect(v.b)
+		verify(t, r == v.intersection, "Case %d: expected intersection %v, got: %v", i, v.intersection, r)
+	}
+
+	verify(t, r1.Contains(rect(2, 2, 2, 2)), "Expected contained rectangle")
+	verify(t, r1.Contains(r1), "Expected rectangle to contain itself")
+	verify(t, !r1.Contains(rect(5, 10, 10, 20)), "Expected overlapping rectangle not contained")
+	verify(t, !rect(2, 2, 2, 2).Contains(r1), "Expected bigger rectangle not contained")
+
+	e := r1.Expand(rect(-5, 30, 1, 1))
+	verify(t, e == Rectangle{Min: Point{-5, 0}, Max: Point{10, 31}}, "Expected expanded rectangle, got: %v", e)
+}
+
 func TestContourAdd(t *T) {
 	c := Contour{}
 	pp := []Point{{1, 2}, {3, 4}, {5, 6}}
@@ -0,0 +1,134 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/akavel/polyclip-go"
+	"github.com/akavel/polyclip-go/polyutil"
+)
+
+// OverlayOptions configure Overlay.
+type OverlayOptions struct {
+	// Workers is the number of goroutines computing the intersections;
+	// runtime.GOMAXPROCS(0) is used if it's not positive.
+	Workers int
+	// AreaOnly makes Overlay leave the Geometry of the pieces empty, only
+	// computing their areas, with polyclip.PreparedPolygon.IntersectionArea:
+	// the contours of the intersections are never built.
+	AreaOnly bool
+}
+
+func (o OverlayOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// OverlayPiece is a non-empty intersection of polygons from two layers.
+type OverlayPiece struct {
+	// AIndex and BIndex are positions of the intersected polygons in their
+	// layers.
+	AIndex, BIndex int
+	// Geometry is the intersection, nil in the area-only mode.
+	Geometry polyclip.Polygon
+	// Area is the area of the intersection, with holes subtracted.
+	Area float64
+}
+
+// Overlay computes intersections of every polygon from layerA with every
+// polygon from layerB, like the "intersect" tools of GIS software, and returns
+// those with a non-zero area, ordered by AIndex and then BIndex. Candidate
+// pairs are found with a packed R-tree of bounding boxes of layerB, and each
+// polygon of layerA is prepared with polyclip.Prepare to be intersected with
+// all its candidates.
+func Overlay(layerA, layerB []polyclip.Polygon, opts OverlayOptions) []OverlayPiece {
+	items := make([]Item, 0, len(layerB))
+	for i, b := range layerB {
+		if b.NumVertices() > 0 {
+			items = append(items, Item{Bounds: b.BoundingBox(), ID: i})
+		}
+	}
+	tree := Pack(items, 0)
+
+	pieces := make([][]OverlayPiece, len(layerA))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pieces[i] = overlayOne(i, layerA[i], layerB, tree, opts.AreaOnly)
+			}
+		}()
+	}
+	for i := range layerA {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var result []OverlayPiece
+	for _, p := range pieces {
+		result = append(result, p...)
+	}
+	return result
+}
+
+// overlayOne intersects polygon a, at position i in its layer, with all
+// polygons of layerB having their bounding boxes in tree overlap it.
+func overlayOne(i int, a polyclip.Polygon, layerB []polyclip.Polygon, tree *Packed, areaOnly bool) []OverlayPiece {
+	if a.NumVertices() == 0 {
+		return nil
+	}
+	var candidates []int
+	tree.Search(a.BoundingBox(), func(it Item) bool {
+		candidates = append(candidates, it.ID)
+		return true
+	})
+	if len(candidates) == 0 {
+		return nil
+	}
+	// the tree yields items in no particular order
+	sort.Ints(candidates)
+
+	pa := polyclip.Prepare(a)
+	var pieces []OverlayPiece
+	for _, j := range candidates {
+		var g polyclip.Polygon
+		var area float64
+		if areaOnly {
+			area = pa.IntersectionArea(layerB[j])
+		} else {
+			g = pa.Construct(polyclip.INTERSECTION, layerB[j])
+			area = polyutil.Area(g)
+		}
+		if area <= 0 {
+			continue
+		}
+		pieces = append(pieces, OverlayPiece{AIndex: i, BIndex: j, Geometry: g, Area: area})
+	}
+	return pieces
+}
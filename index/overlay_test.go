@@ -0,0 +1,90 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"math"
+	"math/rand"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// randomRects returns n axis-aligned rectangles, with coordinates offset by
+// distinct fractions, so that edges of different rectangles don't coincide.
+func randomRects(r *rand.Rand, n int, offset float64) []polyclip.Polygon {
+	polys := []polyclip.Polygon{}
+	for i := 0; i < n; i++ {
+		shift := offset + float64(i+1)/float64(4*n+4)
+		x, y := float64(r.Intn(90))+shift, float64(r.Intn(90))+shift
+		w, h := float64(1+r.Intn(15)), float64(1+r.Intn(15))
+		polys = append(polys, polyclip.Polygon{{
+			{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h},
+		}})
+	}
+	return polys
+}
+
+func TestOverlay(t *T) {
+	r := rand.New(rand.NewSource(5))
+	a, b := randomRects(r, 60, 0), randomRects(r, 80, 0.5)
+	// an empty polygon in each layer shouldn't matter
+	a = append(a, polyclip.Polygon{})
+	b = append([]polyclip.Polygon{{}}, b...)
+
+	type pair struct{ a, b int }
+	expected := map[pair]float64{}
+	for i := range a {
+		for j := range b {
+			if len(a[i]) == 0 || len(b[j]) == 0 {
+				continue
+			}
+			if area := a[i].BoundingBox().Intersect(b[j].BoundingBox()).Area(); area > 0 {
+				expected[pair{i, j}] = area
+			}
+		}
+	}
+
+	for _, opts := range []OverlayOptions{{Workers: 1}, {Workers: 4}, {AreaOnly: true}} {
+		pieces := Overlay(a, b, opts)
+		verify(t, len(pieces) == len(expected), "%+v: expected %d pieces, got: %d", opts, len(expected), len(pieces))
+		for k, p := range pieces {
+			if k > 0 {
+				prev := pieces[k-1]
+				verify(t, prev.AIndex < p.AIndex || (prev.AIndex == p.AIndex && prev.BIndex < p.BIndex),
+					"%+v: pieces out of order: %d,%d before %d,%d", opts, prev.AIndex, prev.BIndex, p.AIndex, p.BIndex)
+			}
+			area, ok := expected[pair{p.AIndex, p.BIndex}]
+			verify(t, ok, "%+v: unexpected piece %d,%d", opts, p.AIndex, p.BIndex)
+			verify(t, math.Abs(p.Area-area) < 1e-9, "%+v: piece %d,%d: expected area %v, got: %v", opts, p.AIndex, p.BIndex, area, p.Area)
+			if opts.AreaOnly {
+				verify(t, p.Geometry == nil, "%+v: expected no geometry, got: %v", opts, p.Geometry)
+				continue
+			}
+			bb := a[p.AIndex].BoundingBox().Intersect(b[p.BIndex].BoundingBox())
+			got := p.Geometry.BoundingBox()
+			close := math.Abs(got.Min.X-bb.Min.X) < 1e-9 && math.Abs(got.Min.Y-bb.Min.Y) < 1e-9 &&
+				math.Abs(got.Max.X-bb.Max.X) < 1e-9 && math.Abs(got.Max.Y-bb.Max.Y) < 1e-9
+			verify(t, close, "%+v: piece %d,%d: expected bounds %v, got: %v", opts, p.AIndex, p.BIndex, bb, got)
+		}
+	}
+
+	verify(t, len(Overlay(nil, b, OverlayOptions{})) == 0, "Expected no pieces for an empty layer")
+}
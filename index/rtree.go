@@ -0,0 +1,454 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package index provides spatial indexes of items described by their bounding
+// rectangles, for quickly finding which polygons of a large set could
+// interact: a dynamic R-tree, allowing insertions and deletions, and a packed
+// one, built once from all items with the Sort-Tile-Recursive algorithm.
+package index
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// Item is an entry of an index: an identifier (e.g. a position in a slice of
+// polygons) together with its bounding box.
+type Item struct {
+	Bounds polyclip.Rectangle
+	ID     int
+}
+
+// Index is implemented by both kinds of R-trees in this package.
+type Index interface {
+	// Len returns the number of items in the index.
+	Len() int
+	// Search calls fn for each item whose bounds overlap r, until fn
+	// returns false.
+	Search(r polyclip.Rectangle, fn func(Item) bool)
+	// Nearest returns up to k items nearest to p, ordered by the distance
+	// from p to their bounds.
+	Nearest(p polyclip.Point, k int) []Item
+}
+
+// DefaultNodeSize is the maximum number of entries in a node of a tree,
+// used when 0 is specified.
+const DefaultNodeSize = 16
+
+// node is a node of an R-tree. Leaves store items, other nodes store
+// children.
+type node struct {
+	bounds   polyclip.Rectangle
+	leaf     bool
+	children []*node
+	items    []Item
+}
+
+func (n *node) len() int {
+	if n.leaf {
+		return len(n.items)
+	}
+	return len(n.children)
+}
+
+// recompute updates the bounds of n from its entries.
+func (n *node) recompute() {
+	first := true
+	add := func(r polyclip.Rectangle) {
+		if first {
+			n.bounds, first = r, false
+		} else {
+			n.bounds = n.bounds.Expand(r)
+		}
+	}
+	for _, it := range n.items {
+		add(it.Bounds)
+	}
+	for _, c := range n.children {
+		add(c.bounds)
+	}
+}
+
+// search calls fn for items overlapping r, and returns false if fn did
+func (n *node) search(r polyclip.Rectangle, fn func(Item) bool) bool {
+	if n.leaf {
+		for _, it := range n.items {
+			if it.Bounds.Overlaps(r) && !fn(it) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, c := range n.children {
+		if c.bounds.Overlaps(r) && !c.search(r, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// distance returns the distance from p to the nearest point of r.
+func distance(p polyclip.Point, r polyclip.Rectangle) float64 {
+	dx := math.Max(0, math.Max(r.Min.X-p.X, p.X-r.Max.X))
+	dy := math.Max(0, math.Max(r.Min.Y-p.Y, p.Y-r.Max.Y))
+	return math.Hypot(dx, dy)
+}
+
+// nearestQueue is a priority queue of nodes and items, ordered by distance,
+// for the best-first nearest neighbor search.
+type nearestQueue []nearestEntry
+
+type nearestEntry struct {
+	dist float64
+	node *node // nil for items
+	item Item
+	seq  int // order of insertion, for stable results
+}
+
+func (q nearestQueue) Len() int { return len(q) }
+func (q nearestQueue) Less(i, j int) bool {
+	if q[i].dist != q[j].dist {
+		return q[i].dist < q[j].dist
+	}
+	return q[i].seq < q[j].seq
+}
+func (q nearestQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nearestQueue) Push(x interface{}) { *q = append(*q, x.(nearestEntry)) }
+func (q *nearestQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+func nearest(root *node, p polyclip.Point, k int) []Item {
+	result := []Item{}
+	if root == nil || k <= 0 {
+		return result
+	}
+	q := &nearestQueue{{dist: distance(p, root.bounds), node: root}}
+	seq := 1
+	for q.Len() > 0 && len(result) < k {
+		e := heap.Pop(q).(nearestEntry)
+		if e.node == nil {
+			result = append(result, e.item)
+			continue
+		}
+		for _, it := range e.node.items {
+			heap.Push(q, nearestEntry{dist: distance(p, it.Bounds), item: it, seq: seq})
+			seq++
+		}
+		for _, c := range e.node.children {
+			heap.Push(q, nearestEntry{dist: distance(p, c.bounds), node: c, seq: seq})
+			seq++
+		}
+	}
+	return result
+}
+
+// RTree is a dynamic R-tree, using the quadratic split algorithm of Guttman.
+// The zero value is not usable; create trees with NewRTree or BulkLoad.
+type RTree struct {
+	root       *node
+	size       int
+	maxEntries int
+	minEntries int
+}
+
+// NewRTree returns an empty tree, with nodes holding up to nodeSize entries
+// (DefaultNodeSize if nodeSize is 0; at least 4).
+func NewRTree(nodeSize int) *RTree {
+	if nodeSize == 0 {
+		nodeSize = DefaultNodeSize
+	}
+	if nodeSize < 4 {
+		nodeSize = 4
+	}
+	return &RTree{
+		root:       &node{leaf: true},
+		maxEntries: nodeSize,
+		minEntries: int(math.Max(2, math.Ceil(float64(nodeSize)*0.4))),
+	}
+}
+
+// Len returns the number of items in the tree.
+func (t *RTree) Len() int { return t.size }
+
+// Search calls fn for each item whose bounds overlap r, until fn returns
+// false.
+func (t *RTree) Search(r polyclip.Rectangle, fn func(Item) bool) {
+	if t.size > 0 {
+		t.root.search(r, fn)
+	}
+}
+
+// Nearest returns up to k items nearest to p, ordered by the distance from p
+// to their bounds.
+func (t *RTree) Nearest(p polyclip.Point, k int) []Item {
+	if t.size == 0 {
+		return []Item{}
+	}
+	return nearest(t.root, p, k)
+}
+
+// enlargement returns how much the area of r grows when expanded by s
+func enlargement(r, s polyclip.Rectangle) float64 {
+	return r.Expand(s).Area() - r.Area()
+}
+
+// Insert adds an item to the tree.
+func (t *RTree) Insert(item Item) {
+	t.insert(&node{leaf: true, items: []Item{item}, bounds: item.Bounds}, 0, true)
+	t.size++
+}
+
+// height returns the number of levels of the tree
+func (t *RTree) height() int {
+	h := 1
+	for n := t.root; !n.leaf; n = n.children[0] {
+		h++
+	}
+	return h
+}
+
+// insert adds the entries of e (an item wrapped in a leaf, if isItem, or
+// a subtree whose leaves are level levels above the leaves of the tree) to
+// the tree.
+func (t *RTree) insert(e *node, level int, isItem bool) {
+	// choose the path down to the node at the right level
+	path := []*node{t.root}
+	n := t.root
+	for depth := t.height() - 1; depth > level; depth-- {
+		best := n.children[0]
+		for _, c := range n.children[1:] {
+			d, bd := enlargement(c.bounds, e.bounds), enlargement(best.bounds, e.bounds)
+			if d < bd || (d == bd && c.bounds.Area() < best.bounds.Area()) {
+				best = c
+			}
+		}
+		n = best
+		path = append(path, n)
+	}
+	if isItem {
+		n.items = append(n.items, e.items[0])
+	} else {
+		n.children = append(n.children, e)
+	}
+
+	// split overflowing nodes and update bounds up the path
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		n.recompute()
+		if n.len() <= t.maxEntries {
+			continue
+		}
+		sibling := t.split(n)
+		if i == 0 {
+			t.root = &node{children: []*node{n, sibling}}
+			t.root.recompute()
+		} else {
+			path[i-1].children = append(path[i-1].children, sibling)
+		}
+	}
+}
+
+// split moves about half of the entries of n to a new node, which is
+// returned, with the quadratic algorithm.
+func (t *RTree) split(n *node) *node {
+	// treat items and children uniformly, by their bounds
+	count := n.len()
+	bounds := make([]polyclip.Rectangle, count)
+	for i := range bounds {
+		if n.leaf {
+			bounds[i] = n.items[i].Bounds
+		} else {
+			bounds[i] = n.children[i].bounds
+		}
+	}
+
+	// pick the two seeds which would waste the most area together
+	s1, s2, worst := 0, 1, math.Inf(-1)
+	for i := 0; i < count; i++ {
+		for j := i + 1; j < count; j++ {
+			d := bounds[i].Expand(bounds[j]).Area() - bounds[i].Area() - bounds[j].Area()
+			if d > worst {
+				s1, s2, worst = i, j, d
+			}
+		}
+	}
+	group := make([]int, count) // 1 or 2 for assigned entries
+	group[s1], group[s2] = 1, 2
+	b1, b2 := bounds[s1], bounds[s2]
+	n1, n2 := 1, 1
+	for assigned := 2; assigned < count; assigned++ {
+		// make sure both groups get at least minEntries
+		left := count - assigned
+		if n1+left == t.minEntries || n2+left == t.minEntries {
+			g := 1
+			if n2+left == t.minEntries {
+				g = 2
+			}
+			for i := range group {
+				if group[i] == 0 {
+					group[i] = g
+				}
+			}
+			break
+		}
+		// pick the entry with the strongest preference for a group
+		next, pref := -1, math.Inf(-1)
+		for i := range group {
+			if group[i] != 0 {
+				continue
+			}
+			d := math.Abs(enlargement(b1, bounds[i]) - enlargement(b2, bounds[i]))
+			if d > pref {
+				next, pref = i, d
+			}
+		}
+		d1, d2 := enlargement(b1, bounds[next]), enlargement(b2, bounds[next])
+		if d1 < d2 || (d1 == d2 && (b1.Area() < b2.Area() || (b1.Area() == b2.Area() && n1 <= n2))) {
+			group[next] = 1
+			b1 = b1.Expand(bounds[next])
+			n1++
+		} else {
+			group[next] = 2
+			b2 = b2.Expand(bounds[next])
+			n2++
+		}
+	}
+
+	sibling := &node{leaf: n.leaf}
+	items, children := n.items, n.children
+	n.items, n.children = nil, nil
+	for i, g := range group {
+		target := n
+		if g == 2 {
+			target = sibling
+		}
+		if n.leaf {
+			target.items = append(target.items, items[i])
+		} else {
+			target.children = append(target.children, children[i])
+		}
+	}
+	n.recompute()
+	sibling.recompute()
+	return sibling
+}
+
+// Delete removes an item equal to the specified one (with the same ID and
+// bounds) from the tree, and reports whether it was found.
+func (t *RTree) Delete(item Item) bool {
+	path := t.find(t.root, item, nil)
+	if path == nil {
+		return false
+	}
+	leaf := path[len(path)-1]
+	for i, it := range leaf.items {
+		if it == item {
+			leaf.items = append(leaf.items[:i], leaf.items[i+1:]...)
+			break
+		}
+	}
+	t.size--
+
+	// remove underfull nodes, reinserting their entries
+	type orphan struct {
+		n     *node
+		level int
+	}
+	orphans := []orphan{}
+	for i := len(path) - 1; i > 0; i-- {
+		n, parent := path[i], path[i-1]
+		if n.len() < t.minEntries {
+			for j, c := range parent.children {
+				if c == n {
+					parent.children = append(parent.children[:j], parent.children[j+1:]...)
+					break
+				}
+			}
+			orphans = append(orphans, orphan{n, len(path) - 1 - i})
+		} else {
+			n.recompute()
+		}
+	}
+	t.root.recompute()
+	// shorten the tree if the root has a single child
+	for !t.root.leaf && len(t.root.children) == 1 {
+		t.root = t.root.children[0]
+	}
+	if !t.root.leaf && len(t.root.children) == 0 {
+		t.root = &node{leaf: true}
+	}
+	for _, o := range orphans {
+		if o.n.leaf {
+			for _, it := range o.n.items {
+				t.insert(&node{leaf: true, items: []Item{it}, bounds: it.Bounds}, 0, true)
+			}
+			continue
+		}
+		for _, c := range o.n.children {
+			t.reinsert(c, o.level-1)
+		}
+	}
+	return true
+}
+
+// reinsert adds a subtree with leaves level levels above the leaves of the
+// tree; if the tree got too short for it, its items are inserted one by one.
+func (t *RTree) reinsert(n *node, level int) {
+	if level < t.height()-1 {
+		t.insert(n, level+1, false)
+		return
+	}
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, it := range n.items {
+			t.insert(&node{leaf: true, items: []Item{it}, bounds: it.Bounds}, 0, true)
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(n)
+}
+
+// find returns the path from n to the leaf containing item, or nil.
+func (t *RTree) find(n *node, item Item, path []*node) []*node {
+	path = append(path, n)
+	if n.leaf {
+		for _, it := range n.items {
+			if it == item {
+				return path
+			}
+		}
+		return nil
+	}
+	for _, c := range n.children {
+		if c.bounds.Contains(item.Bounds) {
+			if p := t.find(c, item, path); p != nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
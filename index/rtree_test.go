@@ -0,0 +1,210 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"math/rand"
+	"sort"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func verify(t *T, cond bool, format string, args ...interface{}) {
+	if !cond {
+		t.Errorf(format, args...)
+	}
+}
+
+var _, _ Index = (*RTree)(nil), (*Packed)(nil)
+
+func randomItems(r *rand.Rand, n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		x, y := r.Float64()*1000, r.Float64()*1000
+		items[i] = Item{ID: i, Bounds: polyclip.Rectangle{
+			Min: polyclip.Point{X: x, Y: y},
+			Max: polyclip.Point{X: x + r.Float64()*20, Y: y + r.Float64()*20},
+		}}
+	}
+	return items
+}
+
+// searchIDs returns sorted IDs of items found in idx overlapping q
+func searchIDs(idx Index, q polyclip.Rectangle) []int {
+	ids := []int{}
+	idx.Search(q, func(it Item) bool {
+		ids = append(ids, it.ID)
+		return true
+	})
+	sort.Ints(ids)
+	return ids
+}
+
+func bruteSearch(items []Item, q polyclip.Rectangle) []int {
+	ids := []int{}
+	for _, it := range items {
+		if it.Bounds.Overlaps(q) {
+			ids = append(ids, it.ID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTree verifies the structure of the tree rooted at n, returning its
+// height and the number of items.
+func checkTree(t *T, n *node, root bool, max int) (height, count int) {
+	verify(t, n.len() <= max, "Node with %d entries, max is %d", n.len(), max)
+	verify(t, root || n.len() > 0, "Empty non-root node")
+	if n.leaf {
+		for _, it := range n.items {
+			verify(t, n.bounds.Contains(it.Bounds), "Node bounds %v don't contain item %v", n.bounds, it)
+		}
+		return 1, len(n.items)
+	}
+	height = -1
+	for _, c := range n.children {
+		verify(t, n.bounds.Contains(c.bounds), "Node bounds %v don't contain child %v", n.bounds, c.bounds)
+		h, k := checkTree(t, c, false, max)
+		verify(t, height == -1 || h == height, "Unbalanced tree: heights %d and %d", height, h)
+		height = h
+		count += k
+	}
+	return height + 1, count
+}
+
+func checkQueries(t *T, r *rand.Rand, name string, idx Index, items []Item) {
+	for i := 0; i < 50; i++ {
+		x, y := r.Float64()*1000, r.Float64()*1000
+		q := polyclip.Rectangle{Min: polyclip.Point{X: x, Y: y}, Max: polyclip.Point{X: x + r.Float64()*100, Y: y + r.Float64()*100}}
+		got, expected := searchIDs(idx, q), bruteSearch(items, q)
+		verify(t, equalInts(got, expected), "%s: query %v: expected %v, got: %v", name, q, expected, got)
+
+		p := polyclip.Point{X: x, Y: y}
+		near := idx.Nearest(p, 5)
+		dists := []float64{}
+		for _, it := range items {
+			dists = append(dists, distance(p, it.Bounds))
+		}
+		sort.Float64s(dists)
+		if len(dists) > 5 {
+			dists = dists[:5]
+		}
+		verify(t, len(near) == len(dists), "%s: expected %d nearest items, got: %d", name, len(dists), len(near))
+		for j := range near {
+			if j < len(dists) {
+				verify(t, distance(p, near[j].Bounds) == dists[j], "%s: nearest %d to %v: expected distance %v, got: %v", name, j, p, dists[j], distance(p, near[j].Bounds))
+			}
+		}
+	}
+}
+
+func TestPacked(t *T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 15, 16, 17, 1000} {
+		items := randomItems(r, n)
+		p := Pack(items, 0)
+		verify(t, p.Len() == n, "Expected %d items, got: %d", n, p.Len())
+		_, count := checkTree(t, p.root, true, DefaultNodeSize)
+		verify(t, count == n, "Expected %d items in the tree, got: %d", n, count)
+		checkQueries(t, r, "packed", p, items)
+	}
+
+	// stopping the search
+	p := Pack(randomItems(r, 100), 4)
+	found := 0
+	p.Search(polyclip.Rectangle{Max: polyclip.Point{X: 1000, Y: 1000}}, func(Item) bool {
+		found++
+		return found < 3
+	})
+	verify(t, found == 3, "Expected search stopped after 3 items, got: %d", found)
+}
+
+func TestRTree(t *T) {
+	r := rand.New(rand.NewSource(2))
+	items := randomItems(r, 2000)
+	tree := NewRTree(8)
+	for _, it := range items {
+		tree.Insert(it)
+	}
+	verify(t, tree.Len() == len(items), "Expected %d items, got: %d", len(items), tree.Len())
+	_, count := checkTree(t, tree.root, true, 8)
+	verify(t, count == len(items), "Expected %d items in the tree, got: %d", len(items), count)
+	checkQueries(t, r, "inserted", tree, items)
+
+	// delete a random half of the items
+	r.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	for _, it := range items[:1000] {
+		verify(t, tree.Delete(it), "Expected item %v deleted", it)
+	}
+	verify(t, !tree.Delete(items[0]), "Expected deleted item not found")
+	items = items[1000:]
+	verify(t, tree.Len() == len(items), "Expected %d items, got: %d", len(items), tree.Len())
+	_, count = checkTree(t, tree.root, true, 8)
+	verify(t, count == len(items), "Expected %d items in the tree, got: %d", len(items), count)
+	checkQueries(t, r, "deleted", tree, items)
+
+	for _, it := range items {
+		tree.Delete(it)
+	}
+	verify(t, tree.Len() == 0 && len(searchIDs(tree, polyclip.Rectangle{Max: polyclip.Point{X: 1000, Y: 1000}})) == 0, "Expected empty tree")
+	verify(t, len(tree.Nearest(polyclip.Point{}, 3)) == 0, "Expected no nearest items in empty tree")
+}
+
+func TestBulkLoad(t *T) {
+	r := rand.New(rand.NewSource(3))
+	items := randomItems(r, 500)
+	tree := BulkLoad(items[:400], 0)
+	for _, it := range items[400:] {
+		tree.Insert(it)
+	}
+	_, count := checkTree(t, tree.root, true, DefaultNodeSize)
+	verify(t, count == len(items), "Expected %d items in the tree, got: %d", len(items), count)
+	checkQueries(t, r, "bulk loaded", tree, items)
+}
+
+func BenchmarkSearch(b *B) {
+	r := rand.New(rand.NewSource(4))
+	items := randomItems(r, 100000)
+	q := polyclip.Rectangle{Min: polyclip.Point{X: 400, Y: 400}, Max: polyclip.Point{X: 450, Y: 450}}
+	for _, idx := range []struct {
+		name string
+		Index
+	}{{"packed", Pack(items, 0)}, {"bulk", BulkLoad(items, 0)}} {
+		b.Run(idx.name, func(b *B) {
+			for i := 0; i < b.N; i++ {
+				idx.Search(q, func(Item) bool { return true })
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"math"
+	"sort"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// Packed is a static R-tree, built at once from all its items with the
+// Sort-Tile-Recursive algorithm. Its nodes are completely filled, which makes
+// it smaller and faster to search than an RTree with the same items, but it
+// can't be modified.
+type Packed struct {
+	root *node
+	size int
+}
+
+// Pack builds a packed tree of the items, with nodes holding up to nodeSize
+// entries (DefaultNodeSize if nodeSize is 0; at least 2). The slice is not
+// modified.
+func Pack(items []Item, nodeSize int) *Packed {
+	if nodeSize == 0 {
+		nodeSize = DefaultNodeSize
+	}
+	if nodeSize < 2 {
+		nodeSize = 2
+	}
+	return &Packed{root: strBuild(items, nodeSize), size: len(items)}
+}
+
+// Len returns the number of items in the tree.
+func (p *Packed) Len() int { return p.size }
+
+// Search calls fn for each item whose bounds overlap r, until fn returns
+// false.
+func (p *Packed) Search(r polyclip.Rectangle, fn func(Item) bool) {
+	if p.size > 0 {
+		p.root.search(r, fn)
+	}
+}
+
+// Nearest returns up to k items nearest to pt, ordered by the distance from
+// pt to their bounds.
+func (p *Packed) Nearest(pt polyclip.Point, k int) []Item {
+	if p.size == 0 {
+		return []Item{}
+	}
+	return nearest(p.root, pt, k)
+}
+
+// BulkLoad returns a dynamic tree containing the items, built with the
+// Sort-Tile-Recursive algorithm, which is much faster than inserting them one
+// by one and gives a better tree.
+func BulkLoad(items []Item, nodeSize int) *RTree {
+	t := NewRTree(nodeSize)
+	if len(items) > 0 {
+		t.root = strBuild(items, t.maxEntries)
+		t.size = len(items)
+	}
+	return t
+}
+
+func center(r polyclip.Rectangle) polyclip.Point {
+	return polyclip.Point{X: (r.Min.X + r.Max.X) / 2, Y: (r.Min.Y + r.Max.Y) / 2}
+}
+
+// strTiles sorts entries (given by their bounds) into tiles of up to size
+// entries: the entries are sorted by X coordinates of their centers, cut
+// into vertical slices, and each slice is sorted by Y and cut into tiles.
+// It returns the permutation of entries and the sizes of consecutive tiles.
+func strTiles(bounds []polyclip.Rectangle, size int) (order []int, tiles []int) {
+	n := len(bounds)
+	order = make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return center(bounds[order[i]]).X < center(bounds[order[j]]).X })
+	ntiles := (n + size - 1) / size
+	slices := int(math.Ceil(math.Sqrt(float64(ntiles))))
+	sliceLen := slices * size
+	for lo := 0; lo < n; lo += sliceLen {
+		hi := lo + sliceLen
+		if hi > n {
+			hi = n
+		}
+		slice := order[lo:hi]
+		sort.SliceStable(slice, func(i, j int) bool { return center(bounds[slice[i]]).Y < center(bounds[slice[j]]).Y })
+		for t := lo; t < hi; t += size {
+			end := t + size
+			if end > hi {
+				end = hi
+			}
+			tiles = append(tiles, end-t)
+		}
+	}
+	return order, tiles
+}
+
+// strBuild builds a tree of the items, level by level from the leaves.
+func strBuild(items []Item, size int) *node {
+	if len(items) == 0 {
+		return &node{leaf: true}
+	}
+	bounds := make([]polyclip.Rectangle, len(items))
+	for i, it := range items {
+		bounds[i] = it.Bounds
+	}
+	order, tiles := strTiles(bounds, size)
+	level := []*node{}
+	k := 0
+	for _, t := range tiles {
+		n := &node{leaf: true}
+		for _, i := range order[k : k+t] {
+			n.items = append(n.items, items[i])
+		}
+		k += t
+		n.recompute()
+		level = append(level, n)
+	}
+
+	for len(level) > 1 {
+		bounds = bounds[:0]
+		for _, n := range level {
+			bounds = append(bounds, n.bounds)
+		}
+		order, tiles = strTiles(bounds, size)
+		parents := []*node{}
+		k = 0
+		for _, t := range tiles {
+			n := &node{}
+			for _, i := range order[k : k+t] {
+				n.children = append(n.children, level[i])
+			}
+			k += t
+			n.recompute()
+			parents = append(parents, n)
+		}
+		level = parents
+	}
+	return level[0]
+}
@@ -0,0 +1,413 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSON encoding of the geometric types is compact: a Point is an [x, y]
+// array, a Contour an array of points, a Polygon an array of contours, and
+// a Rectangle a [min, max] array of two points. The text encoding, meant for
+// config files and the like, is similar: a Point is written as "x y",
+// a Contour as points separated by commas ("0 0, 1 0, 1 1"), a Polygon as
+// parenthesized contours separated by commas ("(0 0, 1 0, 1 1), (2 2, 3 2,
+// 3 3)"), and a Rectangle as its min and max points ("0 0, 1 1"). Decoding is
+// strict: points must have exactly 2 coordinates, and all coordinates must be
+// finite numbers; for the integer coordinate types, integers in range of the
+// type.
+
+// coordBits returns the size of T in bits.
+func coordBits[T Coord]() int {
+	if isInteger[T]() {
+		if x := T(math.MaxInt32); x+1 < x {
+			return 32
+		}
+		return 64
+	}
+	if x := T(1 << 24); x+1 == x {
+		return 32
+	}
+	return 64
+}
+
+// appendNumber appends v formatted like encoding/json formats numbers.
+func appendNumber[T Coord](b []byte, v T) ([]byte, error) {
+	if isInteger[T]() {
+		return strconv.AppendInt(b, int64(v), 10), nil
+	}
+	f, bits := float64(v), coordBits[T]()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return b, fmt.Errorf("unsupported coordinate value %v", f)
+	}
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.AppendFloat(b, f, 'e', -1, bits), nil
+	}
+	return strconv.AppendFloat(b, f, 'f', -1, bits), nil
+}
+
+// parseNumber parses a coordinate of type T. Integer types accept only
+// integers within their range.
+func parseNumber[T Coord](s string) (T, error) {
+	if isInteger[T]() {
+		n, err := strconv.ParseInt(s, 10, coordBits[T]())
+		if err != nil {
+			return 0, fmt.Errorf("invalid coordinate %q", s)
+		}
+		return T(n), nil
+	}
+	f, err := strconv.ParseFloat(s, coordBits[T]())
+	if err != nil {
+		return 0, fmt.Errorf("invalid coordinate %q", s)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("unsupported coordinate value %v", f)
+	}
+	return T(f), nil
+}
+
+func appendPoint[T Coord](b []byte, p PointOf[T], json bool) ([]byte, error) {
+	sep := byte(' ')
+	if json {
+		b = append(b, '[')
+		sep = ','
+	}
+	b, err := appendNumber(b, p.X)
+	if err != nil {
+		return b, err
+	}
+	b = append(b, sep)
+	b, err = appendNumber(b, p.Y)
+	if json {
+		b = append(b, ']')
+	}
+	return b, err
+}
+
+func appendContour[T Coord](b []byte, c ContourOf[T], json bool) ([]byte, error) {
+	sep := ", "
+	if json {
+		b = append(b, '[')
+		sep = ","
+	}
+	var err error
+	for i, p := range c {
+		if i > 0 {
+			b = append(b, sep...)
+		}
+		if b, err = appendPoint(b, p, json); err != nil {
+			return b, err
+		}
+	}
+	if json {
+		b = append(b, ']')
+	}
+	return b, nil
+}
+
+// number is a coordinate decoded from JSON, kept as text so that integers
+// don't lose precision on the way through float64.
+type number string
+
+func (n *number) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if !isNull(data) {
+		*n = number(data)
+	}
+	return nil
+}
+
+// toPoint converts decoded coordinates to a point, validating them.
+func toPoint[T Coord, S ~string](coords []S) (PointOf[T], error) {
+	if len(coords) != 2 {
+		return PointOf[T]{}, fmt.Errorf("expected 2 coordinates, got %d", len(coords))
+	}
+	var xy [2]T
+	for i, s := range coords {
+		if s == "" {
+			return PointOf[T]{}, fmt.Errorf("coordinate %d is null", i)
+		}
+		var err error
+		if xy[i], err = parseNumber[T](string(s)); err != nil {
+			return PointOf[T]{}, err
+		}
+	}
+	return PointOf[T]{xy[0], xy[1]}, nil
+}
+
+func toContour[T Coord](points [][]number) (ContourOf[T], error) {
+	c := make(ContourOf[T], len(points))
+	for i, coords := range points {
+		p, err := toPoint[T](coords)
+		if err != nil {
+			return nil, fmt.Errorf("point %d: %w", i, err)
+		}
+		c[i] = p
+	}
+	return c, nil
+}
+
+// wrapErr adds the package prefix to validation errors of the exported
+// methods; errors of encoding/json are returned as they are.
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("polyclip: %w", err)
+}
+
+// marshaled returns the result of encoding, with the error wrapped.
+func marshaled(b []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return b, nil
+}
+
+func isNull(data []byte) bool {
+	return strings.TrimSpace(string(data)) == "null"
+}
+
+// MarshalJSON encodes p as an [x, y] array.
+func (p PointOf[T]) MarshalJSON() ([]byte, error) {
+	return marshaled(appendPoint(nil, p, true))
+}
+
+// UnmarshalJSON decodes p from an [x, y] array.
+func (p *PointOf[T]) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	var coords []number
+	if err := json.Unmarshal(data, &coords); err != nil {
+		return err
+	}
+	q, err := toPoint[T](coords)
+	if err == nil {
+		*p = q
+	}
+	return wrapErr(err)
+}
+
+// MarshalJSON encodes c as an array of [x, y] arrays.
+func (c ContourOf[T]) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+	return marshaled(appendContour(nil, c, true))
+}
+
+// UnmarshalJSON decodes c from an array of [x, y] arrays.
+func (c *ContourOf[T]) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	var points [][]number
+	if err := json.Unmarshal(data, &points); err != nil {
+		return err
+	}
+	d, err := toContour[T](points)
+	if err == nil {
+		*c = d
+	}
+	return wrapErr(err)
+}
+
+// MarshalJSON encodes p as an array of contours.
+func (p PolygonOf[T]) MarshalJSON() ([]byte, error) {
+	if p == nil {
+		return []byte("null"), nil
+	}
+	b := []byte{'['}
+	var err error
+	for i, c := range p {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if b, err = appendContour(b, c, true); err != nil {
+			return nil, wrapErr(fmt.Errorf("contour %d: %w", i, err))
+		}
+	}
+	return append(b, ']'), nil
+}
+
+// UnmarshalJSON decodes p from an array of contours.
+func (p *PolygonOf[T]) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	var contours [][][]number
+	if err := json.Unmarshal(data, &contours); err != nil {
+		return err
+	}
+	q := make(PolygonOf[T], len(contours))
+	for i, points := range contours {
+		c, err := toContour[T](points)
+		if err != nil {
+			return wrapErr(fmt.Errorf("contour %d: %w", i, err))
+		}
+		q[i] = c
+	}
+	*p = q
+	return nil
+}
+
+// MarshalJSON encodes r as a [min, max] array of points.
+func (r RectangleOf[T]) MarshalJSON() ([]byte, error) {
+	return marshaled(appendContour(nil, ContourOf[T]{r.Min, r.Max}, true))
+}
+
+// UnmarshalJSON decodes r from a [min, max] array of points.
+func (r *RectangleOf[T]) UnmarshalJSON(data []byte) error {
+	if isNull(data) {
+		return nil
+	}
+	var c ContourOf[T]
+	if err := c.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	if len(c) != 2 {
+		return wrapErr(fmt.Errorf("expected 2 points, got %d", len(c)))
+	}
+	*r = RectangleOf[T]{c[0], c[1]}
+	return nil
+}
+
+// parsePoint parses a point in the "x y" text format.
+func parsePoint[T Coord](s string) (PointOf[T], error) {
+	return toPoint[T](strings.Fields(s))
+}
+
+// parseContour parses a contour in the "x y, x y, ..." text format.
+func parseContour[T Coord](s string) (ContourOf[T], error) {
+	if strings.TrimSpace(s) == "" {
+		return ContourOf[T]{}, nil
+	}
+	parts := strings.Split(s, ",")
+	c := make(ContourOf[T], len(parts))
+	for i, part := range parts {
+		p, err := parsePoint[T](part)
+		if err != nil {
+			return nil, fmt.Errorf("point %d: %w", i, err)
+		}
+		c[i] = p
+	}
+	return c, nil
+}
+
+// MarshalText encodes p as "x y".
+func (p PointOf[T]) MarshalText() ([]byte, error) {
+	return marshaled(appendPoint(nil, p, false))
+}
+
+// UnmarshalText decodes p from "x y".
+func (p *PointOf[T]) UnmarshalText(text []byte) error {
+	q, err := parsePoint[T](string(text))
+	if err == nil {
+		*p = q
+	}
+	return wrapErr(err)
+}
+
+// MarshalText encodes c as points separated by commas.
+func (c ContourOf[T]) MarshalText() ([]byte, error) {
+	return marshaled(appendContour(nil, c, false))
+}
+
+// UnmarshalText decodes c from points separated by commas.
+func (c *ContourOf[T]) UnmarshalText(text []byte) error {
+	d, err := parseContour[T](string(text))
+	if err == nil {
+		*c = d
+	}
+	return wrapErr(err)
+}
+
+// MarshalText encodes p as parenthesized contours separated by commas.
+func (p PolygonOf[T]) MarshalText() ([]byte, error) {
+	var b []byte
+	var err error
+	for i, c := range p {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, '(')
+		if b, err = appendContour(b, c, false); err != nil {
+			return nil, wrapErr(fmt.Errorf("contour %d: %w", i, err))
+		}
+		b = append(b, ')')
+	}
+	return b, nil
+}
+
+// UnmarshalText decodes p from parenthesized contours separated by commas.
+func (p *PolygonOf[T]) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	q := PolygonOf[T]{}
+	for i := 0; s != ""; i++ {
+		if i > 0 {
+			if s[0] != ',' {
+				return wrapErr(fmt.Errorf("expected ',' between contours, got %q", s))
+			}
+			s = strings.TrimSpace(s[1:])
+		}
+		if s == "" || s[0] != '(' {
+			return wrapErr(fmt.Errorf("expected '(' starting contour %d", i))
+		}
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return wrapErr(fmt.Errorf("missing ')' closing contour %d", i))
+		}
+		c, err := parseContour[T](s[1:end])
+		if err != nil {
+			return wrapErr(fmt.Errorf("contour %d: %w", i, err))
+		}
+		q = append(q, c)
+		s = strings.TrimSpace(s[end+1:])
+	}
+	*p = q
+	return nil
+}
+
+// MarshalText encodes r as its min and max points separated by a comma.
+func (r RectangleOf[T]) MarshalText() ([]byte, error) {
+	return marshaled(appendContour(nil, ContourOf[T]{r.Min, r.Max}, false))
+}
+
+// UnmarshalText decodes r from its min and max points separated by a comma.
+func (r *RectangleOf[T]) UnmarshalText(text []byte) error {
+	c, err := parseContour[T](string(text))
+	if err != nil {
+		return wrapErr(err)
+	}
+	if len(c) != 2 {
+		return wrapErr(fmt.Errorf("expected 2 points, got %d", len(c)))
+	}
+	*r = RectangleOf[T]{c[0], c[1]}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	. "testing"
+)
+
+func TestMarshalJSON(t *T) {
+	cases := []struct {
+		v        interface{}
+		expected string
+	}{
+		{Point{1.5, -2}, `[1.5,-2]`},
+		{Point{1e21, 1e-7}, `[1e+21,1e-07]`},
+		{Contour{{0, 0}, {1, 0}, {1, 1}}, `[[0,0],[1,0],[1,1]]`},
+		{Contour{}, `[]`},
+		{Polygon{{{0, 0}, {1, 0}, {1, 1}}, {{5, 5}, {6, 5}, {6, 6}}}, `[[[0,0],[1,0],[1,1]],[[5,5],[6,5],[6,6]]]`},
+		{Polygon(nil), `null`},
+		{rect(0, 0, 2, 3), `[[0,0],[2,3]]`},
+		{struct {
+			P Point
+			B *Rectangle
+		}{Point{1, 2}, nil}, `{"P":[1,2],"B":null}`},
+	}
+	for i, c := range cases {
+		b, err := json.Marshal(c.v)
+		verify(t, err == nil && string(b) == c.expected, "Case %d: expected %s, got: %s %v", i, c.expected, b, err)
+	}
+
+	for i, v := range []interface{}{Point{math.NaN(), 0}, Contour{{0, math.Inf(1)}}, Polygon{{{0, 0}}, {{math.Inf(-1), 0}}}} {
+		_, err := json.Marshal(v)
+		verify(t, err != nil, "Non-finite case %d: expected error, got none", i)
+	}
+}
+
+func TestUnmarshalJSON(t *T) {
+	var p Point
+	err := json.Unmarshal([]byte(` [ 1.5 , -2 ] `), &p)
+	verify(t, err == nil && p == Point{1.5, -2}, "Expected point, got: %v %v", p, err)
+	err = json.Unmarshal([]byte(`null`), &p)
+	verify(t, err == nil && p == Point{1.5, -2}, "Expected null to be ignored, got: %v %v", p, err)
+
+	var poly Polygon
+	err = json.Unmarshal([]byte(`[[[0,0],[1,0],[1,1]],[]]`), &poly)
+	verify(t, err == nil && fmt.Sprint(poly) == "[[{0 0} {1 0} {1 1}] []]", "Expected polygon, got: %v %v", poly, err)
+
+	var r Rectangle
+	err = json.Unmarshal([]byte(`[[0,0],[2,3]]`), &r)
+	verify(t, err == nil && r == rect(0, 0, 2, 3), "Expected rectangle, got: %v %v", r, err)
+
+	errors := []struct {
+		input string
+		v     interface{}
+	}{
+		{`[1]`, &Point{}},
+		{`[1,2,3]`, &Point{}},
+		{`{"X":1,"Y":2}`, &Point{}},
+		{`["1",2]`, &Point{}},
+		{`[1,1e400]`, &Point{}},
+		{`[null,5]`, &Point{}},
+		{`[[0,0],[1,null]]`, &Contour{}},
+		{`[[0,0],[1]]`, &Contour{}},
+		{`[[0,0],[1,0],[1,1]]`, &Rectangle{}},
+		{`[[[0,0]],[[1,2,3]]]`, &Polygon{}},
+		{`[[0,0],[1,1]`, &Contour{}},
+	}
+	for i, e := range errors {
+		err := json.Unmarshal([]byte(e.input), e.v)
+		verify(t, err != nil, "Error case %d: expected error, got none", i)
+	}
+	err = json.Unmarshal([]byte(`[[[0,0]],[[1,2,3]]]`), &poly)
+	verify(t, err != nil && err.Error() == "polyclip: contour 1: point 0: expected 2 coordinates, got 3", "Got: %v", err)
+	err = json.Unmarshal([]byte(`[[[0,0]],[[1,null]]]`), &poly)
+	verify(t, err != nil && err.Error() == "polyclip: contour 1: point 0: coordinate 1 is null", "Got: %v", err)
+}
+
+func TestMarshalText(t *T) {
+	cases := []struct {
+		v interface {
+			MarshalText() ([]byte, error)
+		}
+		expected string
+	}{
+		{Point{1.5, -2}, "1.5 -2"},
+		{Contour{{0, 0}, {1, 0}, {1, 1}}, "0 0, 1 0, 1 1"},
+		{Polygon{{{0, 0}, {1, 0}, {1, 1}}, {{5, 5}, {6, 5}, {6, 6}}}, "(0 0, 1 0, 1 1), (5 5, 6 5, 6 6)"},
+		{Polygon{}, ""},
+		{rect(0, 0, 2, 3), "0 0, 2 3"},
+	}
+	for i, c := range cases {
+		b, err := c.v.MarshalText()
+		verify(t, err == nil && string(b) == c.expected, "Case %d: expected %s, got: %s %v", i, c.expected, b, err)
+	}
+
+	var poly Polygon
+	err := poly.UnmarshalText([]byte(" ( 0 0,1 0 , 1 1 ),(5 5, 6 5, 6 6) "))
+	verify(t, err == nil && fmt.Sprint(poly) == "[[{0 0} {1 0} {1 1}] [{5 5} {6 5} {6 6}]]", "Expected polygon, got: %v %v", poly, err)
+	var r Rectangle
+	err = r.UnmarshalText([]byte("0 0, 2 3"))
+	verify(t, err == nil && r == rect(0, 0, 2, 3), "Expected rectangle, got: %v %v", r, err)
+
+	errors := []struct {
+		input string
+		v     interface{ UnmarshalText([]byte) error }
+	}{
+		{"1", &Point{}},
+		{"1 2 3", &Point{}},
+		{"1 NaN", &Point{}},
+		{"1 x", &Point{}},
+		{"0 0, 1", &Contour{}},
+		{"0 0", &Rectangle{}},
+		{"(0 0, 1 0) (1 1)", &Polygon{}},
+		{"(0 0, 1 0", &Polygon{}},
+		{"0 0, 1 0", &Polygon{}},
+	}
+	for i, e := range errors {
+		err := e.v.UnmarshalText([]byte(e.input))
+		verify(t, err != nil, "Error case %d: expected error, got none", i)
+	}
+}
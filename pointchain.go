@@ -28,49 +28,63 @@ package polyclip
 type chain struct {
 	closed bool
 	points []Point
+	// sources[i] is the source of the edge from points[i] to points[i+1]
+	// (or to points[0], for the last edge of a closed chain)
+	sources []EdgeSource
 }
 
-func newChain(s segment) *chain {
+func newChain(s segment, source EdgeSource) *chain {
 	return &chain{
-		closed: false,
-		points: []Point{s.start, s.end}}
+		closed:  false,
+		points:  []Point{s.start, s.end},
+		sources: []EdgeSource{source}}
 }
 
-func (c *chain) pushFront(p Point) { c.points = append([]Point{p}, c.points...) }
-func (c *chain) pushBack(p Point)  { c.points = append(c.points, p) }
+func (c *chain) pushFront(p Point, source EdgeSource) {
+	c.points = append([]Point{p}, c.points...)
+	c.sources = append([]EdgeSource{source}, c.sources...)
+}
+func (c *chain) pushBack(p Point, source EdgeSource) {
+	c.points = append(c.points, p)
+	c.sources = append(c.sources, source)
+}
+func (c *chain) close(source EdgeSource) {
+	c.closed = true
+	c.sources = append(c.sources, source)
+}
 
 // Links a segment to the chain
-func (c *chain) linkSegment(s segment) bool {
+func (c *chain) linkSegment(s segment, source EdgeSource) bool {
 	front := c.points[0]
 	back := c.points[len(c.points)-1]
 
 	switch true {
 	case s.start.Equals(front):
 		if s.end.Equals(back) {
-			c.closed = true
+			c.close(source)
 		} else {
-			c.pushFront(s.end)
+			c.pushFront(s.end, source)
 		}
 		return true
 	case s.end.Equals(back):
 		if s.start.Equals(front) {
-			c.closed = true
+			c.close(source)
 		} else {
-			c.pushBack(s.start)
+			c.pushBack(s.start, source)
 		}
 		return true
 	case s.end.Equals(front):
 		if s.start.Equals(back) {
-			c.closed = true
+			c.close(source)
 		} else {
-			c.pushFront(s.start)
+			c.pushFront(s.start, source)
 		}
 		return true
 	case s.start.Equals(back):
 		if s.end.Equals(front) {
-			c.closed = true
+			c.close(source)
 		} else {
-			c.pushBack(s.end)
+			c.pushBack(s.end, source)
 		}
 		return true
 	}
@@ -88,6 +102,7 @@ func (c *chain) linkChain(other *chain) bool {
 
 	if otherFront.Equals(back) {
 		c.points = append(c.points, other.points[1:]...)
+		c.sources = append(c.sources, other.sources...)
 		goto success
 		//c.points = append(c.points[:len(c.points)-1], other.points...)
 		//return true
@@ -95,6 +110,7 @@ func (c *chain) linkChain(other *chain) bool {
 
 	if otherBack.Equals(front) {
 		c.points = append(other.points, c.points[1:]...)
+		c.sources = append(other.sources, c.sources...)
 		goto success
 		//return true
 	}
@@ -102,12 +118,14 @@ func (c *chain) linkChain(other *chain) bool {
 	if otherFront.Equals(front) {
 		// Remove the first element, and join to reversed chain.points
 		c.points = append(reversed(other.points), c.points[1:]...)
+		c.sources = append(reversedSources(other.sources), c.sources...)
 		goto success
 		//return true
 	}
 
 	if otherBack.Equals(back) {
 		c.points = append(c.points[:len(c.points)-1], reversed(other.points)...)
+		c.sources = append(c.sources, reversedSources(other.sources)...)
 		goto success
 		//c.points = append(other.points, reversed(c.points)...)
 		//return true
@@ -117,12 +135,22 @@ func (c *chain) linkChain(other *chain) bool {
 
 success:
 	other.points = []Point{}
+	other.sources = nil
 	return true
 }
 
-func reversed(list []Point) []Point {
+func reversed[T Coord](list []PointOf[T]) []PointOf[T] {
+	length := len(list)
+	other := make([]PointOf[T], length)
+	for i := range list {
+		other[length-i-1] = list[i]
+	}
+	return other
+}
+
+func reversedSources(list []EdgeSource) []EdgeSource {
 	length := len(list)
-	other := make([]Point, length)
+	other := make([]EdgeSource, length)
 	for i := range list {
 		other[length-i-1] = list[i]
 	}
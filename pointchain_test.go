@@ -29,3 +29,34 @@ func TestChainLinkChain(t *T) {
 	verify(t, a.linkChain(&b), "Expected being able to link chains")
 	verify(t, len(a.points) == 5, "Expected len==5, got %d", len(a.points))
 }
+
+func TestChainSources(t *T) {
+	// each segment's source records its position in the final contour,
+	// which is 0-1-2-3-4-5
+	pts := []Point{{0, 0}, {1, 0}, {2, 1}, {1, 2}, {0, 2}, {-1, 1}}
+	seg := func(i, j int) (segment, EdgeSource) {
+		edge := i
+		if (j+1)%len(pts) == i {
+			edge = j
+		}
+		return segment{pts[i], pts[j]}, EdgeSource{Edge: edge}
+	}
+	a := newChain(seg(2, 1))
+	verify(t, a.linkSegment(seg(2, 3)), "Expected being able to link segment")
+	b := newChain(seg(4, 5))
+	verify(t, b.linkSegment(seg(3, 4)), "Expected being able to link segment")
+	verify(t, a.linkChain(b), "Expected being able to link chains")
+	verify(t, a.linkSegment(seg(0, 5)), "Expected being able to link segment")
+	verify(t, a.linkSegment(seg(1, 0)) && a.closed, "Expected closed chain")
+	verify(t, len(a.sources) == len(a.points), "Expected %d sources, got: %v", len(a.points), a.sources)
+	for i, p := range a.points {
+		q := a.points[(i+1)%len(a.points)]
+		// find the edge in pts, in any direction
+		for j := range pts {
+			k := (j + 1) % len(pts)
+			if p == pts[j] && q == pts[k] || p == pts[k] && q == pts[j] {
+				verify(t, a.sources[i].Edge == j, "Edge %v-%v: expected source %d, got: %v", p, q, j, a.sources[i])
+			}
+		}
+	}
+}
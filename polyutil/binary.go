@@ -0,0 +1,309 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// The binary format of a polygon consists of:
+//
+//	magic "PLYC", version byte, encoding byte
+//	quantum (float64), only for the quantized encoding
+//	number of contours (uvarint)
+//	for each contour: number of points (uvarint), then the points
+//	CRC-32 (IEEE) of all the preceding bytes
+//
+// All fixed-size numbers are little endian. In the float64 encoding, each
+// point is stored as two float64 numbers. In the quantized encoding, the
+// coordinates are rounded to multiples of the quantum, and stored as
+// differences from the previous point (across contours, starting from the
+// origin) in zigzag varints.
+const (
+	binaryMagic   = "PLYC"
+	binaryVersion = 1
+
+	binaryFloat64   = 0
+	binaryQuantized = 1
+)
+
+// ErrChecksum is returned when decoding binary data with an invalid checksum.
+var ErrChecksum = errors.New("polyutil: binary polygon checksum mismatch")
+
+// maxQuantized is the largest absolute value of a quantized coordinate, so
+// that differences between coordinates don't overflow int64.
+const maxQuantized = 1 << 62
+
+// BinaryEncoder writes polygons in a compact binary format. Each polygon is
+// written as a separate record, with its own header and checksum.
+type BinaryEncoder struct {
+	w       *bufio.Writer
+	quantum float64
+	buf     []byte
+}
+
+// NewBinaryEncoder returns an encoder writing to w. If quantum is 0, the
+// coordinates are stored exactly as float64 numbers; otherwise, they are
+// rounded to multiples of quantum and delta-encoded, which usually takes
+// a few bytes per point.
+func NewBinaryEncoder(w io.Writer, quantum float64) *BinaryEncoder {
+	return &BinaryEncoder{w: bufio.NewWriter(w), quantum: quantum}
+}
+
+// check returns an error if p can't be encoded, so that Encode doesn't write
+// a partial record.
+func (e *BinaryEncoder) check(p polyclip.Polygon) error {
+	q := e.quantum
+	if q < 0 || math.IsNaN(q) || math.IsInf(q, 0) {
+		return fmt.Errorf("polyutil: invalid binary quantum %v", q)
+	}
+	if len(p) > math.MaxInt32 {
+		return fmt.Errorf("polyutil: too many contours for binary polygon: %d", len(p))
+	}
+	for _, c := range p {
+		if len(c) > math.MaxInt32 {
+			return fmt.Errorf("polyutil: too many points in binary polygon contour: %d", len(c))
+		}
+		if q == 0 {
+			continue
+		}
+		for _, pt := range c {
+			x, y := math.Round(pt.X/q), math.Round(pt.Y/q)
+			if !(math.Abs(x) <= maxQuantized && math.Abs(y) <= maxQuantized) {
+				return fmt.Errorf("polyutil: point %v out of range for binary quantum %v", pt, q)
+			}
+		}
+	}
+	return nil
+}
+
+// Encode writes p to the underlying writer. If p can't be encoded, nothing is
+// written.
+func (e *BinaryEncoder) Encode(p polyclip.Polygon) error {
+	if err := e.check(p); err != nil {
+		return err
+	}
+	q := e.quantum
+	crc := crc32.NewIEEE()
+	out := io.MultiWriter(e.w, crc)
+	// flush writes the contents of buf, reusing it afterwards
+	flush := func() error {
+		_, err := out.Write(e.buf)
+		e.buf = e.buf[:0]
+		return err
+	}
+
+	b := append(e.buf[:0], binaryMagic...)
+	b = append(b, binaryVersion)
+	if q == 0 {
+		b = append(b, binaryFloat64)
+	} else {
+		b = append(b, binaryQuantized)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(q))
+	}
+	b = binary.AppendUvarint(b, uint64(len(p)))
+	e.buf = b
+
+	var lastX, lastY int64
+	for _, c := range p {
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(c)))
+		for _, pt := range c {
+			if q == 0 {
+				e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(pt.X))
+				e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(pt.Y))
+			} else {
+				x, y := math.Round(pt.X/q), math.Round(pt.Y/q)
+				e.buf = binary.AppendVarint(e.buf, int64(x)-lastX)
+				e.buf = binary.AppendVarint(e.buf, int64(y)-lastY)
+				lastX, lastY = int64(x), int64(y)
+			}
+		}
+		if len(e.buf) >= 4096 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, crc.Sum32())
+	if _, err := e.w.Write(e.buf); err != nil {
+		return err
+	}
+	e.buf = e.buf[:0]
+	return e.w.Flush()
+}
+
+// BinaryDecoder reads polygons written by a BinaryEncoder.
+type BinaryDecoder struct {
+	r   *bufio.Reader
+	crc uint32
+	buf [8]byte
+}
+
+// NewBinaryDecoder returns a decoder reading from r. The decoder buffers its
+// input, so it may read data from r beyond the polygons requested.
+func NewBinaryDecoder(r io.Reader) *BinaryDecoder {
+	return &BinaryDecoder{r: bufio.NewReader(r)}
+}
+
+// ReadByte implements io.ByteReader, updating the checksum of the record
+func (d *BinaryDecoder) ReadByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err == nil {
+		d.buf[0] = b
+		d.crc = crc32.Update(d.crc, crc32.IEEETable, d.buf[:1])
+	}
+	return b, err
+}
+
+func (d *BinaryDecoder) read(n int) ([]byte, error) {
+	_, err := io.ReadFull(d.r, d.buf[:n])
+	if err == nil {
+		d.crc = crc32.Update(d.crc, crc32.IEEETable, d.buf[:n])
+	}
+	return d.buf[:n], err
+}
+
+func (d *BinaryDecoder) float64() (float64, error) {
+	b, err := d.read(8)
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), err
+}
+
+// count reads a number of elements, and returns how many of them can be
+// preallocated, so that corrupt input doesn't cause huge allocations.
+func (d *BinaryDecoder) count() (n, prealloc int, err error) {
+	u, err := binary.ReadUvarint(d)
+	if err == nil && u > math.MaxInt32 {
+		err = fmt.Errorf("polyutil: invalid binary polygon count %d", u)
+	}
+	n = int(u)
+	prealloc = n
+	if prealloc > 1<<16 {
+		prealloc = 1 << 16
+	}
+	return n, prealloc, err
+}
+
+// Decode reads the next polygon. At the end of input, it returns io.EOF.
+func (d *BinaryDecoder) Decode() (polyclip.Polygon, error) {
+	d.crc = 0
+	header, err := d.read(6)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.decode(header)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return p, err
+}
+
+func (d *BinaryDecoder) decode(header []byte) (polyclip.Polygon, error) {
+	if string(header[:4]) != binaryMagic {
+		return nil, errors.New("polyutil: not a binary polygon")
+	}
+	if header[4] != binaryVersion {
+		return nil, fmt.Errorf("polyutil: unsupported binary polygon version %d", header[4])
+	}
+	var err error
+	quantized := false
+	q := 0.0
+	switch header[5] {
+	case binaryFloat64:
+	case binaryQuantized:
+		quantized = true
+		if q, err = d.float64(); err != nil {
+			return nil, err
+		}
+		if !(q > 0) || math.IsInf(q, 0) {
+			return nil, fmt.Errorf("polyutil: invalid binary quantum %v", q)
+		}
+	default:
+		return nil, fmt.Errorf("polyutil: unsupported binary polygon encoding %d", header[5])
+	}
+
+	ncontours, prealloc, err := d.count()
+	if err != nil {
+		return nil, err
+	}
+	p := make(polyclip.Polygon, 0, prealloc)
+	var x, y int64
+	for i := 0; i < ncontours; i++ {
+		npoints, prealloc, err := d.count()
+		if err != nil {
+			return nil, err
+		}
+		c := make(polyclip.Contour, 0, prealloc)
+		for j := 0; j < npoints; j++ {
+			var pt polyclip.Point
+			if quantized {
+				dx, err := binary.ReadVarint(d)
+				if err != nil {
+					return nil, err
+				}
+				dy, err := binary.ReadVarint(d)
+				if err != nil {
+					return nil, err
+				}
+				x, y = x+dx, y+dy
+				pt = polyclip.Point{X: float64(x) * q, Y: float64(y) * q}
+			} else {
+				if pt.X, err = d.float64(); err != nil {
+					return nil, err
+				}
+				if pt.Y, err = d.float64(); err != nil {
+					return nil, err
+				}
+			}
+			c = append(c, pt)
+		}
+		p = append(p, c)
+	}
+
+	sum := d.crc
+	b, err := d.read(4)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(b) != sum {
+		return nil, ErrChecksum
+	}
+	return p, nil
+}
+
+// EncodeBinary writes p to w in the binary format of BinaryEncoder.
+func EncodeBinary(w io.Writer, p polyclip.Polygon, quantum float64) error {
+	return NewBinaryEncoder(w, quantum).Encode(p)
+}
+
+// DecodeBinary reads a single polygon written by EncodeBinary.
+func DecodeBinary(r io.Reader) (polyclip.Polygon, error) {
+	return NewBinaryDecoder(r).Decode()
+}
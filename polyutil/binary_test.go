@@ -0,0 +1,213 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/rand"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestBinaryEncodeDecode(t *T) {
+	p := polyclip.Polygon{
+		{{X: 0, Y: 0}, {X: 10.5, Y: 0}, {X: 10.5, Y: -10.25}},
+		{},
+		{{X: 1e9, Y: -1e9}, {X: 0.1, Y: 0.2}, {X: math.Pi, Y: math.E}},
+	}
+
+	buf := &bytes.Buffer{}
+	err := EncodeBinary(buf, p, 0)
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	q, err := DecodeBinary(buf)
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	verify(t, fmt.Sprint(q) == fmt.Sprint(p), "Expected %v, got: %v", p, q)
+
+	buf.Reset()
+	err = EncodeBinary(buf, p, 0.001)
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	q, err = DecodeBinary(buf)
+	verify(t, err == nil && len(q) == len(p), "Expected %v, got: %v %v", p, q, err)
+	for i := range p {
+		for j := range p[i] {
+			if i >= len(q) || j >= len(q[i]) {
+				break
+			}
+			d := math.Max(math.Abs(p[i][j].X-q[i][j].X), math.Abs(p[i][j].Y-q[i][j].Y))
+			verify(t, d <= 0.0005, "Point %d/%d: expected %v, got: %v", i, j, p[i][j], q[i][j])
+		}
+	}
+
+	err = EncodeBinary(buf, polyclip.Polygon{{{X: 1e300, Y: 0}}}, 0.001)
+	verify(t, err != nil, "Expected out of range error")
+	err = EncodeBinary(buf, p, -1)
+	verify(t, err != nil, "Expected invalid quantum error")
+}
+
+func TestBinaryEncodeNothingOnError(t *T) {
+	// enough points before the invalid one to fill the internal buffer
+	c := polyclip.Contour{}
+	for i := 0; i < 2000; i++ {
+		c.Add(polyclip.Point{X: float64(i), Y: float64(i % 7)})
+	}
+	p := polyclip.Polygon{c, {{X: 1e300, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}}
+
+	buf := &bytes.Buffer{}
+	err := EncodeBinary(buf, p, 0.001)
+	verify(t, err != nil, "Expected out of range error")
+	verify(t, buf.Len() == 0, "Expected nothing written, got %d bytes", buf.Len())
+
+	// the encoder is still usable afterwards
+	e := NewBinaryEncoder(buf, 0.001)
+	err = e.Encode(p)
+	verify(t, err != nil, "Expected out of range error")
+	err = e.Encode(p[:1])
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	q, err := DecodeBinary(buf)
+	verify(t, err == nil && len(q) == 1 && len(q[0]) == len(c), "Expected %d points, got: %v", len(c), err)
+}
+
+func TestBinaryStream(t *T) {
+	polys := []polyclip.Polygon{
+		{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}},
+		{},
+		{{{X: 5, Y: 5}, {X: 6, Y: 5}, {X: 6, Y: 6}}, {{X: 7, Y: 7}, {X: 8, Y: 7}, {X: 8, Y: 8}}},
+	}
+	buf := &bytes.Buffer{}
+	e := NewBinaryEncoder(buf, 0.5)
+	for _, p := range polys {
+		err := e.Encode(p)
+		verify(t, err == nil, "Expected no error, got: %v", err)
+	}
+	data := buf.Bytes()
+
+	d := NewBinaryDecoder(bytes.NewReader(data))
+	for i, p := range polys {
+		q, err := d.Decode()
+		verify(t, err == nil && fmt.Sprint(q) == fmt.Sprint(p), "Polygon %d: expected %v, got: %v %v", i, p, q, err)
+	}
+	_, err := d.Decode()
+	verify(t, err == io.EOF, "Expected EOF, got: %v", err)
+
+	// corrupted data
+	corrupt := append([]byte{}, data...)
+	corrupt[len(corrupt)-6] ^= 1
+	d = NewBinaryDecoder(bytes.NewReader(corrupt))
+	d.Decode()
+	d.Decode()
+	_, err = d.Decode()
+	verify(t, err == ErrChecksum, "Expected checksum error, got: %v", err)
+
+	for _, n := range []int{3, 10, len(data) - 2} {
+		d = NewBinaryDecoder(bytes.NewReader(data[len(data)-n:]))
+		_, err = d.Decode()
+		verify(t, err != nil && err != io.EOF, "Truncated to %d: expected error, got: %v", n, err)
+	}
+	_, err = DecodeBinary(bytes.NewReader(data[:10]))
+	verify(t, err == io.ErrUnexpectedEOF, "Expected unexpected EOF, got: %v", err)
+}
+
+func TestBinaryDecodeInvalidQuantum(t *T) {
+	for _, q := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		// a record with a single point and a valid checksum
+		b := append([]byte(binaryMagic), binaryVersion, binaryQuantized)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(q))
+		b = append(b, 1, 1, 2, 2)
+		b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+		p, err := DecodeBinary(bytes.NewReader(b))
+		verify(t, err != nil, "Quantum %v: expected error, got: %v", q, p)
+	}
+}
+
+// benchPolygon returns a polygon with contours of random walks, similar to
+// digitized map data.
+func benchPolygon() polyclip.Polygon {
+	r := rand.New(rand.NewSource(1))
+	p := polyclip.Polygon{}
+	for i := 0; i < 100; i++ {
+		c := polyclip.Contour{}
+		pt := polyclip.Point{X: r.Float64() * 1e5, Y: r.Float64() * 1e5}
+		for j := 0; j < 1000; j++ {
+			pt.X += math.Round(r.NormFloat64()*1000) / 1000
+			pt.Y += math.Round(r.NormFloat64()*1000) / 1000
+			c.Add(pt)
+		}
+		p.Add(c)
+	}
+	return p
+}
+
+var benchFormats = []struct {
+	name   string
+	encode func(io.Writer, polyclip.Polygon) error
+	decode func(io.Reader) error
+}{
+	{"text",
+		EncodePolygon,
+		func(r io.Reader) error { _, err := DecodePolygon(r); return err }},
+	{"float64",
+		func(w io.Writer, p polyclip.Polygon) error { return EncodeBinary(w, p, 0) },
+		func(r io.Reader) error { _, err := DecodeBinary(r); return err }},
+	{"quantized",
+		func(w io.Writer, p polyclip.Polygon) error { return EncodeBinary(w, p, 0.001) },
+		func(r io.Reader) error { _, err := DecodeBinary(r); return err }},
+}
+
+func BenchmarkEncode(b *B) {
+	p := benchPolygon()
+	for _, f := range benchFormats {
+		b.Run(f.name, func(b *B) {
+			buf := &bytes.Buffer{}
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := f.encode(buf, p); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(buf.Len()), "size")
+		})
+	}
+}
+
+func BenchmarkDecode(b *B) {
+	p := benchPolygon()
+	for _, f := range benchFormats {
+		b.Run(f.name, func(b *B) {
+			buf := &bytes.Buffer{}
+			if err := f.encode(buf, p); err != nil {
+				b.Fatal(err)
+			}
+			data := buf.Bytes()
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := f.decode(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -27,15 +27,174 @@ import (
 	"fmt"
 	"github.com/akavel/polyclip-go"
 	"io"
+	"strconv"
 )
 
-// EncodeContour serializes all points of a specified contour using a simple textual format.
+// Ring is a contour together with the hole flag of the GPC file format,
+// telling whether the contour bounds a hole in the polygon rather than its
+// outside.
+type Ring struct {
+	polyclip.Contour
+	Hole bool
+}
+
+// Rings is a polygon with each contour explicitly marked as an outer boundary
+// or a hole, as in files of the GPC library.
+type Rings []Ring
+
+// ClassifyRings marks the contours of p which are nested in an odd number of
+// other contours as holes. Contours are assumed not to cross each other. Each
+// pair of contours with nested bounding boxes is tested for containment, so
+// this may take time quadratic in the number of contours.
+func ClassifyRings(p polyclip.Polygon) Rings {
+	depth, _ := nesting(p)
+	r := make(Rings, len(p))
+	for i, c := range p {
+		r[i] = Ring{Contour: c, Hole: depth[i]%2 == 1}
+	}
+	return r
+}
+
+// Polygon returns the contours of r, dropping the hole flags. Flags are not
+// needed by polyclip, which tells holes from outer boundaries by nesting.
+func (r Rings) Polygon() polyclip.Polygon {
+	p := make(polyclip.Polygon, len(r))
+	for i := range r {
+		p[i] = r[i].Contour
+	}
+	return p
+}
+
+// FormatError describes malformed input encountered while decoding polygons.
+type FormatError struct {
+	Line int // line of the input on which the error was found, counting from 1
+	Err  error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("polyutil: line %d: %v", e.Line, e.Err)
+}
+
+func (e *FormatError) Unwrap() error { return e.Err }
+
+// byteReader reads a single byte at a time from a reader which is not an
+// io.ByteReader, so that nothing past the decoded data is consumed.
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(r.r, r.buf[:])
+	return r.buf[0], err
+}
+
+// gpcScanner splits the textual format into whitespace-separated tokens,
+// keeping track of line numbers.
+type gpcScanner struct {
+	r    io.ByteReader
+	line int
+	last int // line of the last token read
+}
+
+func newGPCScanner(in io.Reader) *gpcScanner {
+	r, ok := in.(io.ByteReader)
+	if !ok {
+		r = &byteReader{r: in}
+	}
+	return &gpcScanner{r: r, line: 1}
+}
+
+func isGPCSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\v' || c == '\f'
+}
+
+// token returns the next token, and the line on which it started. The
+// whitespace character terminating the token is consumed. At the end of
+// input, an empty token and io.EOF are returned, with the line of the last
+// token.
+func (s *gpcScanner) token() (string, int, error) {
+	c, err := s.r.ReadByte()
+	for err == nil && isGPCSpace(c) {
+		if c == '\n' {
+			s.line++
+		}
+		c, err = s.r.ReadByte()
+	}
+	if err != nil {
+		return "", s.last, err
+	}
+	line := s.line
+	s.last = line
+	tok := []byte{c}
+	for {
+		c, err = s.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", line, err
+		}
+		if isGPCSpace(c) {
+			if c == '\n' {
+				s.line++
+			}
+			break
+		}
+		tok = append(tok, c)
+	}
+	return string(tok), line, nil
+}
+
+// int reads an integer described by what; negative values are rejected
+// unless signed is true
+func (s *gpcScanner) int(what string, signed bool) (int, error) {
+	tok, line, err := s.token()
+	if err == io.EOF {
+		return 0, &FormatError{line, fmt.Errorf("unexpected end of input, expected %s", what)}
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(tok)
+	if err != nil || (n < 0 && !signed) {
+		return 0, &FormatError{line, fmt.Errorf("expected %s, got %q", what, tok)}
+	}
+	return n, nil
+}
+
+func (s *gpcScanner) float(what string) (float64, error) {
+	tok, line, err := s.token()
+	if err == io.EOF {
+		return 0, &FormatError{line, fmt.Errorf("unexpected end of input, expected %s", what)}
+	}
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return 0, &FormatError{line, fmt.Errorf("expected %s, got %q", what, tok)}
+	}
+	return f, nil
+}
+
+// EncodeContour serializes all points of a specified contour using a simple textual format,
+// compatible with the GPC library. The contour is marked as an outer boundary.
 func EncodeContour(w io.Writer, c polyclip.Contour) error {
-	_, err := fmt.Fprint(w, len(c), " 1\n")
+	return EncodeRing(w, Ring{Contour: c})
+}
+
+// EncodeRing serializes all points of a contour together with its hole flag.
+func EncodeRing(w io.Writer, r Ring) error {
+	hole := 0
+	if r.Hole {
+		hole = 1
+	}
+	_, err := fmt.Fprint(w, len(r.Contour), " ", hole, "\n")
 	if err != nil {
 		return err
 	}
-	for _, p := range c {
+	for _, p := range r.Contour {
 		_, err = fmt.Fprint(w, "\t", p.X, " ", p.Y, "\n")
 		if err != nil {
 			return err
@@ -45,6 +204,9 @@ func EncodeContour(w io.Writer, c polyclip.Contour) error {
 }
 
 // EncodePolygon serializes all contours of a polygon using a simple textual format.
+// All contours are marked as outer boundaries, which polyclip ignores anyway;
+// to write the hole flags expected by GPC, use EncodeRings with the result of
+// ClassifyRings instead.
 func EncodePolygon(w io.Writer, p polyclip.Polygon) error {
 	_, err := fmt.Fprint(w, len(p), "\n")
 	if err != nil {
@@ -59,24 +221,86 @@ func EncodePolygon(w io.Writer, p polyclip.Polygon) error {
 	return nil
 }
 
-// DecodePolygon loads a polygon saved using EncodePolygon function.
+// EncodeRings serializes all contours of a polygon together with their hole flags.
+func EncodeRings(w io.Writer, rings Rings) error {
+	_, err := fmt.Fprint(w, len(rings), "\n")
+	if err != nil {
+		return err
+	}
+	for _, r := range rings {
+		err = EncodeRing(w, r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodePolygon loads a polygon saved using EncodePolygon function. Malformed
+// input is reported with a *FormatError; io.EOF is returned if the input
+// ends before the polygon starts. No data past the end of the polygon is
+// consumed from in, so it can be called repeatedly to read consecutive
+// polygons.
 func DecodePolygon(in io.Reader) (*polyclip.Polygon, error) {
-	var ncontours int
-	_, err := fmt.Fscan(in, &ncontours)
+	rings, err := DecodeRings(in)
 	if err != nil {
 		return nil, err
 	}
-	polygon := polyclip.Polygon{}
+	polygon := rings.Polygon()
+	return &polygon, nil
+}
+
+// DecodeRings loads a polygon saved using EncodeRings or EncodePolygon
+// function, or by the GPC library with hole flags, keeping the hole flags of
+// its contours. As in GPC, any non-zero flag marks a hole. Repeated points
+// and contours with less than 3 distinct points are dropped.
+func DecodeRings(in io.Reader) (Rings, error) {
+	return decodeRings(newGPCScanner(in))
+}
+
+// DecodePolygons loads all polygons saved one after another using
+// EncodePolygon function, until the end of input.
+func DecodePolygons(in io.Reader) ([]polyclip.Polygon, error) {
+	s := newGPCScanner(in)
+	polygons := []polyclip.Polygon{}
+	for {
+		rings, err := decodeRings(s)
+		if err == io.EOF {
+			return polygons, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		polygons = append(polygons, rings.Polygon())
+	}
+}
+
+func decodeRings(s *gpcScanner) (Rings, error) {
+	tok, line, err := s.token()
+	if err != nil {
+		return nil, err
+	}
+	ncontours, err := strconv.Atoi(tok)
+	if err != nil || ncontours < 0 {
+		return nil, &FormatError{line, fmt.Errorf("expected number of contours, got %q", tok)}
+	}
+	rings := Rings{}
 	for i := 0; i < ncontours; i++ {
-		var npoints, level int
-		_, err = fmt.Fscan(in, &npoints, &level)
+		npoints, err := s.int("number of points", false)
+		if err != nil {
+			return nil, err
+		}
+		hole, err := s.int("hole flag", true)
 		if err != nil {
 			return nil, err
 		}
 		c := polyclip.Contour{}
 		for j := 0; j < npoints; j++ {
 			p := polyclip.Point{}
-			_, err = fmt.Fscan(in, &p.X, &p.Y)
+			p.X, err = s.float("X coordinate")
+			if err == nil {
+				p.Y, err = s.float("Y coordinate")
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -91,7 +315,7 @@ func DecodePolygon(in io.Reader) (*polyclip.Polygon, error) {
 		if len(c) < 3 {
 			continue
 		}
-		polygon.Add(c)
+		rings = append(rings, Ring{Contour: c, Hole: hole != 0})
 	}
-	return &polygon, nil
+	return rings, nil
 }
@@ -21,7 +21,10 @@ package polyutil
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/akavel/polyclip-go"
+	"io"
+	"strings"
 	. "testing"
 )
 
@@ -32,7 +35,7 @@ func verify(t *T, cond bool, format string, args ...interface{}) {
 }
 
 func TestPolygonDecodeEncode(t *T) {
-	txt1 := "1\n3 1\n\t0 0\n\t1 1\n\t0.5 0.5\n"
+	txt1 := "1\n3 0\n\t0 0\n\t1 1\n\t0.5 0.5\n"
 
 	p, err := DecodePolygon(bytes.NewBufferString(txt1))
 	verify(t, err == nil, "Expected no error decoding, got: %v", err)
@@ -48,3 +51,70 @@ func TestPolygonDecodeEncode(t *T) {
 	verify(t, err == nil, "Expected no error encoding, got: %v", err)
 	verify(t, buf.String() == txt1, "Expected: %v, got: %v", txt1, buf)
 }
+
+func TestRingsDecodeEncode(t *T) {
+	txt := "3\n4 0\n\t0 0\n\t10 0\n\t10 10\n\t0 10\n" +
+		"4 1\n\t2 2\n\t8 2\n\t8 8\n\t2 8\n" +
+		"3 0\n\t4 4\n\t6 4\n\t6 6\n"
+
+	rings, err := DecodeRings(strings.NewReader(txt))
+	verify(t, err == nil, "Expected no error decoding, got: %v", err)
+	verify(t, fmt.Sprint(rings) == "[{[{0 0} {10 0} {10 10} {0 10}] false} {[{2 2} {8 2} {8 8} {2 8}] true} {[{4 4} {6 4} {6 6}] false}]", "Got: %v", rings)
+
+	buf := &bytes.Buffer{}
+	err = EncodeRings(buf, rings)
+	verify(t, err == nil && buf.String() == txt, "Expected: %v, got: %v %v", txt, buf, err)
+
+	// flags computed from nesting match the ones in the file
+	buf.Reset()
+	err = EncodeRings(buf, ClassifyRings(rings.Polygon()))
+	verify(t, err == nil && buf.String() == txt, "Expected: %v, got: %v %v", txt, buf, err)
+
+	// EncodePolygon doesn't classify the contours
+	buf.Reset()
+	err = EncodePolygon(buf, rings.Polygon())
+	expected := strings.Replace(txt, "4 1\n", "4 0\n", 1)
+	verify(t, err == nil && buf.String() == expected, "Expected: %v, got: %v %v", expected, buf, err)
+
+	// GPC treats any non-zero flag as a hole
+	rings, err = DecodeRings(strings.NewReader("1 3 -1 0 0 1 0 1 1"))
+	verify(t, err == nil && len(rings) == 1 && rings[0].Hole, "Expected a hole, got: %v %v", rings, err)
+}
+
+func TestDecodePolygons(t *T) {
+	txt := "1\n3 0\n0 0\n1 0\n1 1\n\n0\n2\n3 0 5 5 6 5 6 6\n3 0 7 7 8 7 8 8\n"
+	polys, err := DecodePolygons(strings.NewReader(txt))
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	verify(t, fmt.Sprint(polys) == "[[[{0 0} {1 0} {1 1}]] [] [[{5 5} {6 5} {6 6}] [{7 7} {8 7} {8 8}]]]", "Got: %v", polys)
+
+	polys, err = DecodePolygons(strings.NewReader(""))
+	verify(t, err == nil && len(polys) == 0, "Expected no polygons, got: %v %v", polys, err)
+
+	// DecodePolygon doesn't read past the end of a polygon, even from
+	// a reader which is not an io.ByteReader
+	r := io.MultiReader(strings.NewReader(txt))
+	for i := 0; i < 3; i++ {
+		_, err := DecodePolygon(r)
+		verify(t, err == nil, "Polygon %d: expected no error, got: %v", i, err)
+	}
+	_, err = DecodePolygon(r)
+	verify(t, err == io.EOF, "Expected EOF, got: %v", err)
+
+	errors := []struct {
+		input string
+		line  int
+	}{
+		{"x", 1},
+		{"1\n3 0\n0 0\n1 0\n1 y\n", 5},
+		{"1\n-3 0\n", 2},
+		{"1\n3 0\n0 0\n1 0\n", 4},
+		{"1\n3 0\n0 0\n1 0\n1 1\n\n2\n3 z", 8},
+	}
+	for i, e := range errors {
+		_, err := DecodePolygons(strings.NewReader(e.input))
+		ferr, ok := err.(*FormatError)
+		verify(t, ok && ferr.Line == e.line, "Error case %d: expected error on line %d, got: %v", i, e.line, err)
+	}
+	_, err = DecodePolygon(strings.NewReader("1\n3 0\n0 0\n"))
+	verify(t, strings.Contains(fmt.Sprint(err), "line 3: unexpected end of input"), "Got: %v", err)
+}
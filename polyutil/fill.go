@@ -0,0 +1,210 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// FillRule describes which points are treated as being inside a polygon.
+type FillRule int
+
+const (
+	// EvenOdd treats a point as inside if a ray cast from it crosses the
+	// contours an odd number of times. This is the rule used by polyclip.
+	EvenOdd FillRule = iota
+	// NonZero treats a point as inside if the contours wind around it a
+	// non-zero number of times.
+	NonZero
+)
+
+// Putcoverage describes a function expected to draw a point on a bitmap at (x, y)
+// coordinates, with the specified fraction (from range (0, 1]) of the pixel
+// covered by the shape.
+type Putcoverage func(x, y int, coverage float64)
+
+// FillPolygon rasterizes the interior of p, according to the specified fill
+// rule, using a scanline algorithm with an active edge table. Pixel (x, y) is
+// treated as a unit square with top-left corner at (x, y), and is drawn if its
+// center is inside p. The computed points are passed to brush function for
+// final rendering.
+func FillPolygon(p polyclip.Polygon, rule FillRule, brush Putpixel) {
+	s := newScanner(p, rule)
+	if s == nil {
+		return
+	}
+	for y := int(math.Floor(s.minY)); float64(y) < s.maxY; y++ {
+		s.spans(float64(y)+0.5, func(x0, x1 float64) {
+			for x := int(math.Ceil(x0 - 0.5)); float64(x)+0.5 < x1; x++ {
+				brush(x, y)
+			}
+		})
+	}
+}
+
+// Number of subscanlines per pixel used by FillPolygonAA.
+const aaSubscanlines = 16
+
+// FillPolygonAA works like FillPolygon, but additionally computes what part of
+// each pixel is covered by p, giving anti-aliased output. The coverage is
+// exact horizontally, and approximated using 16 samples vertically.
+func FillPolygonAA(p polyclip.Polygon, rule FillRule, brush Putcoverage) {
+	inf := math.Inf(1)
+	fillAA(p, rule, -inf, -inf, inf, inf, brush)
+}
+
+// FillPolygonAAClipped works like FillPolygonAA, but only computes the pixels
+// within clip. The time taken depends on the part of p inside clip, so this
+// should be used when drawing a small part of a large polygon.
+func FillPolygonAAClipped(p polyclip.Polygon, rule FillRule, clip image.Rectangle, brush Putcoverage) {
+	fillAA(p, rule, float64(clip.Min.X), float64(clip.Min.Y), float64(clip.Max.X), float64(clip.Max.Y), brush)
+}
+
+// fillAA implements FillPolygonAA for pixels within the [x0, x1) x [y0, y1)
+// range.
+func fillAA(p polyclip.Polygon, rule FillRule, x0, y0, x1, y1 float64, brush Putcoverage) {
+	s := newScanner(p, rule)
+	if s == nil {
+		return
+	}
+	minX := math.Max(math.Floor(s.minX), x0)
+	maxX := math.Min(math.Ceil(s.maxX), x1-1)
+	maxY := math.Min(s.maxY, y1)
+	if maxX < minX {
+		return
+	}
+	row := make([]float64, int(maxX-minX)+1)
+	for y := int(math.Max(math.Floor(s.minY), y0)); float64(y) < maxY; y++ {
+		for i := range row {
+			row[i] = 0
+		}
+		for sub := 0; sub < aaSubscanlines; sub++ {
+			sy := float64(y) + (float64(sub)+0.5)/aaSubscanlines
+			s.spans(sy, func(sx0, sx1 float64) {
+				for x := int(math.Max(math.Floor(sx0), minX)); float64(x) < sx1 && float64(x) <= maxX; x++ {
+					covered := math.Min(sx1, float64(x+1)) - math.Max(sx0, float64(x))
+					row[x-int(minX)] += covered / aaSubscanlines
+				}
+			})
+		}
+		for i, c := range row {
+			if c > 0 {
+				brush(int(minX)+i, y, math.Min(c, 1))
+			}
+		}
+	}
+}
+
+type fillEdge struct {
+	x0, y0, x1, y1 float64 // y0 < y1
+	dir            int     // +1 if the edge goes down in the contour, -1 if up
+}
+
+func (e *fillEdge) x(y float64) float64 {
+	return e.x0 + (y-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+}
+
+// scanner finds spans of a polygon interior along horizontal lines, visited
+// from top to bottom, using an active edge table.
+type scanner struct {
+	rule                   FillRule
+	edges                  []fillEdge // sorted by y0
+	next                   int        // index of first edge in edges not yet activated
+	active                 []*fillEdge
+	minX, minY, maxX, maxY float64
+	crossings              []crossing
+}
+
+type crossing struct {
+	x   float64
+	dir int
+}
+
+func newScanner(p polyclip.Polygon, rule FillRule) *scanner {
+	s := &scanner{
+		rule: rule,
+		minX: math.Inf(1), minY: math.Inf(1),
+		maxX: math.Inf(-1), maxY: math.Inf(-1),
+	}
+	for _, c := range p {
+		for i := range c {
+			a, b := c[i], c[(i+1)%len(c)]
+			s.minX, s.maxX = math.Min(s.minX, a.X), math.Max(s.maxX, a.X)
+			s.minY, s.maxY = math.Min(s.minY, a.Y), math.Max(s.maxY, a.Y)
+			switch {
+			case a.Y < b.Y:
+				s.edges = append(s.edges, fillEdge{a.X, a.Y, b.X, b.Y, 1})
+			case a.Y > b.Y:
+				s.edges = append(s.edges, fillEdge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+	if len(s.edges) == 0 {
+		return nil
+	}
+	sort.Slice(s.edges, func(i, j int) bool { return s.edges[i].y0 < s.edges[j].y0 })
+	return s
+}
+
+// spans calls fn for each span [x0, x1) of the polygon interior along the
+// horizontal line at y. The y values must not decrease between calls.
+func (s *scanner) spans(y float64, fn func(x0, x1 float64)) {
+	// update the active edge table: an edge is active for y in [y0, y1)
+	for s.next < len(s.edges) && s.edges[s.next].y0 <= y {
+		s.active = append(s.active, &s.edges[s.next])
+		s.next++
+	}
+	active := s.active[:0]
+	for _, e := range s.active {
+		if e.y1 > y {
+			active = append(active, e)
+		}
+	}
+	s.active = active
+
+	s.crossings = s.crossings[:0]
+	for _, e := range s.active {
+		s.crossings = append(s.crossings, crossing{e.x(y), e.dir})
+	}
+	sort.Slice(s.crossings, func(i, j int) bool { return s.crossings[i].x < s.crossings[j].x })
+
+	winding, start := 0, 0.0
+	for _, c := range s.crossings {
+		wasInside := s.inside(winding)
+		winding += c.dir
+		switch isInside := s.inside(winding); {
+		case !wasInside && isInside:
+			start = c.x
+		case wasInside && !isInside && c.x > start:
+			fn(start, c.x)
+		}
+	}
+}
+
+func (s *scanner) inside(winding int) bool {
+	if s.rule == EvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
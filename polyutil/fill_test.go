@@ -0,0 +1,106 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"image"
+	"math"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func square(x, y, size float64, clockwise bool) polyclip.Contour {
+	c := polyclip.Contour{{X: x, Y: y}, {X: x + size, Y: y}, {X: x + size, Y: y + size}, {X: x, Y: y + size}}
+	if !clockwise {
+		c[1], c[3] = c[3], c[1]
+	}
+	return c
+}
+
+func TestFillPolygon(t *T) {
+	cases := []struct {
+		p      polyclip.Polygon
+		rule   FillRule
+		pixels int
+		hole   bool // is pixel (5, 5) expected to be empty
+	}{
+		{polyclip.Polygon{square(0, 0, 10, true)}, EvenOdd, 100, false},
+		{polyclip.Polygon{square(0, 0, 10, true)}, NonZero, 100, false},
+		{polyclip.Polygon{square(0, 0, 10, true), square(3, 3, 4, true)}, EvenOdd, 84, true},
+		{polyclip.Polygon{square(0, 0, 10, true), square(3, 3, 4, true)}, NonZero, 100, false},
+		{polyclip.Polygon{square(0, 0, 10, true), square(3, 3, 4, false)}, NonZero, 84, true},
+		{polyclip.Polygon{square(0.2, 0.2, 2, true)}, EvenOdd, 4, false},
+		{polyclip.Polygon{square(0.3, 0.3, 0.5, true)}, EvenOdd, 1, false},
+		{polyclip.Polygon{{{X: 0, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}, {X: 10, Y: 0}}}, EvenOdd, 50, false},
+		{polyclip.Polygon{}, EvenOdd, 0, false},
+	}
+	for i, c := range cases {
+		pixels := map[[2]int]int{}
+		FillPolygon(c.p, c.rule, func(x, y int) {
+			pixels[[2]int{x, y}]++
+		})
+		verify(t, len(pixels) == c.pixels, "Case %d: expected %d pixels, got %d", i, c.pixels, len(pixels))
+		for p, n := range pixels {
+			verify(t, n == 1, "Case %d: pixel %v drawn %d times", i, p, n)
+		}
+		if c.pixels >= 84 {
+			_, drawn := pixels[[2]int{5, 5}]
+			verify(t, drawn != c.hole, "Case %d: expected hole==%v", i, c.hole)
+		}
+	}
+}
+
+func TestFillPolygonAA(t *T) {
+	coverage := map[[2]int]float64{}
+	FillPolygonAA(polyclip.Polygon{square(0.5, 0.5, 2, true)}, EvenOdd, func(x, y int, c float64) {
+		coverage[[2]int{x, y}] = c
+	})
+	expected := map[[2]int]float64{
+		{0, 0}: 0.25, {1, 0}: 0.5, {2, 0}: 0.25,
+		{0, 1}: 0.5, {1, 1}: 1, {2, 1}: 0.5,
+		{0, 2}: 0.25, {1, 2}: 0.5, {2, 2}: 0.25,
+	}
+	verify(t, len(coverage) == len(expected), "Expected %d pixels, got: %v", len(expected), coverage)
+	for p, c := range expected {
+		verify(t, math.Abs(coverage[p]-c) < 1e-9, "Expected coverage %v at %v, got %v", c, p, coverage[p])
+	}
+}
+
+func TestFillPolygonAAClipped(t *T) {
+	p := polyclip.Polygon{square(0.5, 0.5, 2, true)}
+	all := map[[2]int]float64{}
+	FillPolygonAA(p, EvenOdd, func(x, y int, c float64) { all[[2]int{x, y}] = c })
+	clipped := map[[2]int]float64{}
+	FillPolygonAAClipped(p, EvenOdd, image.Rect(1, 0, 3, 2), func(x, y int, c float64) { clipped[[2]int{x, y}] = c })
+	verify(t, len(clipped) == 4, "Expected 4 pixels, got: %v", clipped)
+	for p, c := range clipped {
+		verify(t, p[0] >= 1 && p[0] < 3 && p[1] >= 0 && p[1] < 2 && c == all[p], "Unexpected coverage %v at %v", c, p)
+	}
+
+	// only the pixels within clip are visited
+	huge := polyclip.Polygon{square(-1e9, -1e9, 2e9, true)}
+	n := 0
+	FillPolygonAAClipped(huge, NonZero, image.Rect(-5, -5, 5, 5), func(x, y int, c float64) {
+		verify(t, c == 1, "Expected full coverage at %d,%d, got %v", x, y, c)
+		n++
+	})
+	verify(t, n == 100, "Expected 100 pixels, got %d", n)
+}
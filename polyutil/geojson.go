@@ -0,0 +1,196 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// GeoJSONFeature is a polygon read from or written to GeoJSON, together with
+// the identifier and properties of the feature it comes from.
+type GeoJSONFeature struct {
+	ID         interface{}            // nil if the feature has no "id"
+	Properties map[string]interface{} // nil if the feature has null or no "properties"
+	Polygon    polyclip.Polygon
+}
+
+type geoJSONObject struct {
+	Type        string                 `json:"type"`
+	ID          interface{}            `json:"id,omitempty"`
+	Properties  map[string]interface{} `json:"properties"`
+	Geometry    *geoJSONObject         `json:"geometry,omitempty"`
+	Features    []geoJSONObject        `json:"features,omitempty"`
+	Coordinates json.RawMessage        `json:"coordinates,omitempty"`
+}
+
+// DecodeGeoJSON reads a GeoJSON (RFC 7946) object: a FeatureCollection, a
+// Feature, or a bare Polygon or MultiPolygon geometry. Each feature is
+// returned with its polygon built of all the rings (outer boundaries and
+// holes) of its geometry, as contours; the repeated closing positions of the
+// rings are dropped. A bare geometry is returned as a feature without ID and
+// properties, and a feature with null geometry as one with an empty polygon.
+// Other geometry types result in an error.
+func DecodeGeoJSON(r io.Reader) ([]GeoJSONFeature, error) {
+	obj := geoJSONObject{}
+	err := json.NewDecoder(r).Decode(&obj)
+	if err != nil {
+		return nil, err
+	}
+	switch obj.Type {
+	case "FeatureCollection":
+		features := make([]GeoJSONFeature, len(obj.Features))
+		for i := range obj.Features {
+			features[i], err = decodeGeoJSONFeature(&obj.Features[i])
+			if err != nil {
+				return nil, fmt.Errorf("polyutil: GeoJSON feature %d: %v", i, err)
+			}
+		}
+		return features, nil
+	case "Feature":
+		f, err := decodeGeoJSONFeature(&obj)
+		if err != nil {
+			return nil, fmt.Errorf("polyutil: GeoJSON feature: %v", err)
+		}
+		return []GeoJSONFeature{f}, nil
+	}
+	p, err := decodeGeoJSONGeometry(&obj)
+	if err != nil {
+		return nil, fmt.Errorf("polyutil: GeoJSON: %v", err)
+	}
+	return []GeoJSONFeature{{Polygon: p}}, nil
+}
+
+func decodeGeoJSONFeature(obj *geoJSONObject) (GeoJSONFeature, error) {
+	if obj.Type != "Feature" {
+		return GeoJSONFeature{}, fmt.Errorf("expected type Feature, got %q", obj.Type)
+	}
+	f := GeoJSONFeature{ID: obj.ID, Properties: obj.Properties, Polygon: polyclip.Polygon{}}
+	if obj.Geometry == nil {
+		return f, nil
+	}
+	var err error
+	f.Polygon, err = decodeGeoJSONGeometry(obj.Geometry)
+	return f, err
+}
+
+func decodeGeoJSONGeometry(obj *geoJSONObject) (polyclip.Polygon, error) {
+	var polys [][][][]float64
+	switch obj.Type {
+	case "Polygon":
+		var rings [][][]float64
+		err := json.Unmarshal(obj.Coordinates, &rings)
+		if err != nil {
+			return nil, err
+		}
+		polys = [][][][]float64{rings}
+	case "MultiPolygon":
+		err := json.Unmarshal(obj.Coordinates, &polys)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", obj.Type)
+	}
+
+	p := polyclip.Polygon{}
+	for _, rings := range polys {
+		for _, ring := range rings {
+			c := polyclip.Contour{}
+			for _, pos := range ring {
+				if len(pos) < 2 {
+					return nil, fmt.Errorf("position with %d coordinates", len(pos))
+				}
+				c.Add(polyclip.Point{X: pos[0], Y: pos[1]})
+			}
+			if len(c) > 1 && c[0].Equals(c[len(c)-1]) {
+				c = c[:len(c)-1]
+			}
+			if len(c) < 3 {
+				return nil, fmt.Errorf("ring with %d distinct positions", len(c))
+			}
+			p.Add(c)
+		}
+	}
+	return p, nil
+}
+
+// EncodeGeoJSON writes the features as a GeoJSON (RFC 7946)
+// FeatureCollection. The contours of each polygon are classified as outer
+// boundaries or holes based on their nesting (see DecodeGeoJSON), and written
+// as a Polygon geometry if there's a single outer boundary, or a MultiPolygon
+// otherwise. As required by RFC 7946, the outer rings are written
+// counter-clockwise and holes clockwise, and all rings are closed by repeating
+// their first position. A feature with an empty polygon gets a null geometry.
+func EncodeGeoJSON(w io.Writer, features ...GeoJSONFeature) error {
+	type feature struct {
+		Type       string                 `json:"type"`
+		ID         interface{}            `json:"id,omitempty"`
+		Geometry   interface{}            `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	fc := struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection", Features: []feature{}}
+	for _, f := range features {
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			ID:         f.ID,
+			Geometry:   encodeGeoJSONGeometry(f.Polygon),
+			Properties: f.Properties,
+		})
+	}
+	return json.NewEncoder(w).Encode(fc)
+}
+
+func encodeGeoJSONGeometry(p polyclip.Polygon) interface{} {
+	ring := func(c polyclip.Contour, counterClockwise bool) [][2]float64 {
+		c = oriented(c, counterClockwise)
+		r := make([][2]float64, 0, len(c)+1)
+		for _, p := range c {
+			r = append(r, [2]float64{p.X, p.Y})
+		}
+		return append(r, r[0])
+	}
+	polys := [][][][2]float64{}
+	for _, g := range groupRings(p) {
+		rings := [][][2]float64{ring(g.outer, true)}
+		for _, h := range g.holes {
+			rings = append(rings, ring(h, false))
+		}
+		polys = append(polys, rings)
+	}
+
+	type geometry struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}
+	switch len(polys) {
+	case 0:
+		return nil
+	case 1:
+		return geometry{"Polygon", polys[0]}
+	}
+	return geometry{"MultiPolygon", polys}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestDecodeGeoJSON(t *T) {
+	input := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "id": "a", "properties": {"name": "square", "n": 1},
+		 "geometry": {"type": "Polygon", "coordinates": [
+			[[0,0], [10,0], [10,10], [0,10], [0,0]],
+			[[2,2], [2,8], [8,8], [8,2], [2,2]]]}},
+		{"type": "Feature", "properties": null,
+		 "geometry": {"type": "MultiPolygon", "coordinates": [
+			[[[0,0,100], [1,0,100], [1,1,100], [0,0,100]]],
+			[[[5,5], [6,5], [6,6]]]]}},
+		{"type": "Feature", "properties": {}, "geometry": null}
+	]}`
+	features, err := DecodeGeoJSON(strings.NewReader(input))
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	verify(t, len(features) == 3, "Expected 3 features, got: %v", features)
+	if len(features) != 3 {
+		return
+	}
+	verify(t, features[0].ID == "a", "Expected id a, got: %v", features[0].ID)
+	verify(t, features[0].Properties["name"] == "square" && features[0].Properties["n"] == 1.0, "Expected properties, got: %v", features[0].Properties)
+	verify(t, fmt.Sprint(features[0].Polygon) == "[[{0 0} {10 0} {10 10} {0 10}] [{2 2} {2 8} {8 8} {8 2}]]", "Got: %v", features[0].Polygon)
+	verify(t, features[1].ID == nil && features[1].Properties == nil, "Expected no id and properties, got: %v", features[1])
+	verify(t, fmt.Sprint(features[1].Polygon) == "[[{0 0} {1 0} {1 1}] [{5 5} {6 5} {6 6}]]", "Got: %v", features[1].Polygon)
+	verify(t, len(features[2].Polygon) == 0, "Expected empty polygon, got: %v", features[2].Polygon)
+
+	features, err = DecodeGeoJSON(strings.NewReader(`{"type": "Polygon", "coordinates": [[[0,0], [1,0], [1,1], [0,0]]]}`))
+	verify(t, err == nil && len(features) == 1 && len(features[0].Polygon) == 1, "Expected bare polygon, got: %v %v", features, err)
+
+	errors := []string{
+		`{"type": "Point", "coordinates": [0, 0]}`,
+		`{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}}`,
+		`{"type": "Polygon", "coordinates": [[[0,0], [1,0], [0,0]]]}`,
+		`{"type": "Polygon", "coordinates": [[[0], [1,0], [1,1]]]}`,
+		`{"type": "Polygon"`,
+	}
+	for i, e := range errors {
+		_, err := DecodeGeoJSON(strings.NewReader(e))
+		verify(t, err != nil, "Error case %d: expected error, got none", i)
+	}
+}
+
+func TestEncodeGeoJSON(t *T) {
+	cw := func(c polyclip.Contour) polyclip.Contour { return oriented(c, false) }
+	ccw := func(c polyclip.Contour) polyclip.Contour { return oriented(c, true) }
+	outer := polyclip.Contour{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	hole := polyclip.Contour{{X: 2, Y: 2}, {X: 8, Y: 2}, {X: 8, Y: 8}, {X: 2, Y: 8}}
+	island := polyclip.Contour{{X: 4, Y: 4}, {X: 6, Y: 4}, {X: 6, Y: 6}}
+
+	buf := &bytes.Buffer{}
+	err := EncodeGeoJSON(buf,
+		GeoJSONFeature{ID: 7.0, Properties: map[string]interface{}{"k": "v"}, Polygon: polyclip.Polygon{cw(hole), cw(outer)}},
+		GeoJSONFeature{Polygon: polyclip.Polygon{ccw(island), ccw(hole), ccw(outer)}},
+		GeoJSONFeature{Polygon: polyclip.Polygon{}})
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	expected := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","id":7,"geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[2,8],[8,8],[8,2],[2,2],[2,8]]]},"properties":{"k":"v"}},` +
+		`{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[4,4],[6,4],[6,6],[4,4]]],[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[2,8],[8,8],[8,2],[2,2],[2,8]]]]},"properties":null},` +
+		`{"type":"Feature","geometry":null,"properties":null}]}` + "\n"
+	verify(t, buf.String() == expected, "Expected:\n%s\ngot:\n%s", expected, buf)
+
+	features, err := DecodeGeoJSON(buf)
+	verify(t, err == nil && len(features) == 3, "Expected round-trip, got: %v %v", features, err)
+	if err == nil {
+		verify(t, features[0].ID == 7.0 && features[0].Properties["k"] == "v", "Expected id and properties round-tripped, got: %v", features[0])
+		verify(t, len(features[1].Polygon) == 3, "Expected 3 contours, got: %v", features[1].Polygon)
+	}
+}
+
+func TestGeoJSONNullGeometry(t *T) {
+	input := `{"type":"FeatureCollection","features":[{"type":"Feature","id":"a","geometry":null,"properties":{"k":"v"}}]}` + "\n"
+	features, err := DecodeGeoJSON(strings.NewReader(input))
+	verify(t, err == nil && len(features) == 1 && len(features[0].Polygon) == 0, "Expected a feature with empty polygon, got: %v %v", features, err)
+
+	buf := &bytes.Buffer{}
+	err = EncodeGeoJSON(buf, features...)
+	verify(t, err == nil && buf.String() == input, "Expected:\n%s\ngot:\n%s %v", input, buf, err)
+}
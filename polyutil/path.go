@@ -0,0 +1,261 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// Path builds a polygon from drawing commands, like those of SVG paths or
+// font outlines. Curves and arcs are flattened into line segments, using
+// adaptive subdivision, so that they deviate from the exact shape by no more
+// than the tolerance given to NewPath. Each subpath becomes a contour of the
+// polygon; consecutive duplicate points are dropped.
+//
+// The current point, where the next segment starts, is initially (0, 0).
+type Path struct {
+	tolerance  float64
+	poly       polyclip.Polygon
+	c          polyclip.Contour // the current subpath
+	cur, start polyclip.Point
+}
+
+// NewPath returns an empty path, flattening curves with given tolerance. It
+// panics if tolerance isn't positive (or is NaN).
+func NewPath(tolerance float64) *Path {
+	if !(tolerance > 0) {
+		panic(fmt.Sprintf("polyutil: path flattening tolerance must be positive, got %v", tolerance))
+	}
+	return &Path{tolerance: tolerance, poly: polyclip.Polygon{}}
+}
+
+// Current returns the current point of the path.
+func (p *Path) Current() polyclip.Point {
+	return p.cur
+}
+
+// MoveTo ends the current subpath, and starts a new one at pt.
+func (p *Path) MoveTo(pt polyclip.Point) {
+	p.flush()
+	p.cur, p.start = pt, pt
+}
+
+// LineTo adds a straight segment from the current point to pt.
+func (p *Path) LineTo(pt polyclip.Point) {
+	if len(p.c) == 0 {
+		p.c.Add(p.cur)
+	}
+	if !pt.Equals(p.c[len(p.c)-1]) {
+		p.c.Add(pt)
+	}
+	p.cur = pt
+}
+
+// QuadTo adds a quadratic Bézier curve from the current point to pt, with
+// control point ctrl.
+func (p *Path) QuadTo(ctrl, pt polyclip.Point) {
+	p.lineTo(flattenQuad(p.cur, ctrl, pt, p.tolerance))
+}
+
+// CubicTo adds a cubic Bézier curve from the current point to pt, with
+// control points ctrl1 and ctrl2.
+func (p *Path) CubicTo(ctrl1, ctrl2, pt polyclip.Point) {
+	p.lineTo(flattenCubic(p.cur, ctrl1, ctrl2, pt, p.tolerance))
+}
+
+// ArcTo adds an elliptical arc from the current point to pt, like the SVG
+// "A" path command: rx and ry are the radii (equal for a circular arc), and
+// rotation is the angle in degrees of the x axis of the ellipse. Of the four
+// arcs matching these, large selects one spanning more than 180 degrees, and
+// sweep one going in the direction of increasing angles. Radii too small to
+// reach pt are scaled up; if either is zero, the arc is a straight line.
+func (p *Path) ArcTo(rx, ry, rotation float64, large, sweep bool, pt polyclip.Point) {
+	p.lineTo(flattenArc(p.cur, rx, ry, rotation, large, sweep, pt, p.tolerance))
+}
+
+// Close ends the current subpath, connecting it back to its first point,
+// which becomes the current point.
+func (p *Path) Close() {
+	p.flush()
+	p.cur = p.start
+}
+
+// Polygon returns the polygon built so far. An unfinished subpath is
+// included, closed like by Close, but the path can still be extended.
+// Subpaths with fewer than 3 distinct points, which enclose no area, are
+// left out.
+func (p *Path) Polygon() polyclip.Polygon {
+	poly := p.poly.Clone()
+	if c := closed(p.c); len(c) > 0 {
+		poly.Add(c.Clone())
+	}
+	return poly
+}
+
+func (p *Path) lineTo(pts []polyclip.Point) {
+	for _, pt := range pts {
+		p.LineTo(pt)
+	}
+}
+
+// flush moves the current subpath into the polygon.
+func (p *Path) flush() {
+	if c := closed(p.c); len(c) > 0 {
+		p.poly.Add(c)
+	}
+	p.c = nil
+}
+
+// closed returns c without its last point, if it's the same as the first, or
+// nil if c has fewer than 3 distinct points.
+func closed(c polyclip.Contour) polyclip.Contour {
+	if len(c) > 1 && c[len(c)-1].Equals(c[0]) {
+		c = c[:len(c)-1]
+	}
+	// find the second distinct point, then a third one
+	for i := 1; i < len(c); i++ {
+		if c[i].Equals(c[0]) {
+			continue
+		}
+		for _, pt := range c[i+1:] {
+			if !pt.Equals(c[0]) && !pt.Equals(c[i]) {
+				return c
+			}
+		}
+		break
+	}
+	return nil
+}
+
+// flattenCubic returns points approximating the cubic Bézier curve p0-p3,
+// excluding p0, using adaptive subdivision.
+func flattenCubic(p0, p1, p2, p3 polyclip.Point, tolerance float64) []polyclip.Point {
+	return subdivideCubic(p0, p1, p2, p3, tolerance, 0)
+}
+
+// Maximum depth of subdivision of Bézier curves; this allows for up to 65536
+// segments per curve, and protects from looping on invalid coordinates.
+const maxSubdivision = 16
+
+func subdivideCubic(p0, p1, p2, p3 polyclip.Point, tolerance float64, depth int) []polyclip.Point {
+	// the curve lies within the convex hull of the control points, so no
+	// farther from the chord than they are
+	if depth == maxSubdivision || distToSegment(p1, p0, p3) <= tolerance && distToSegment(p2, p0, p3) <= tolerance {
+		return []polyclip.Point{p3}
+	}
+	mid := func(a, b polyclip.Point) polyclip.Point {
+		return polyclip.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+	}
+	p01, p12, p23 := mid(p0, p1), mid(p1, p2), mid(p2, p3)
+	p012, p123 := mid(p01, p12), mid(p12, p23)
+	m := mid(p012, p123)
+	return append(subdivideCubic(p0, p01, p012, m, tolerance, depth+1), subdivideCubic(m, p123, p23, p3, tolerance, depth+1)...)
+}
+
+// flattenQuad returns points approximating the quadratic Bézier curve p0-p2,
+// excluding p0.
+func flattenQuad(p0, p1, p2 polyclip.Point, tolerance float64) []polyclip.Point {
+	// elevate to a cubic curve
+	c1 := polyclip.Point{X: p0.X + 2*(p1.X-p0.X)/3, Y: p0.Y + 2*(p1.Y-p0.Y)/3}
+	c2 := polyclip.Point{X: p2.X + 2*(p1.X-p2.X)/3, Y: p2.Y + 2*(p1.Y-p2.Y)/3}
+	return flattenCubic(p0, c1, c2, p2, tolerance)
+}
+
+// arcSegments returns the number of segments needed to approximate an arc of
+// given radius and angle, so that chords don't deviate from it by more than
+// tolerance.
+func arcSegments(r, angle, tolerance float64) int {
+	n := 1
+	if r > tolerance {
+		step := 2 * math.Acos(1-tolerance/r)
+		n = int(math.Ceil(math.Abs(angle) / step))
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > 1<<maxSubdivision {
+		n = 1 << maxSubdivision
+	}
+	if math.Abs(angle) >= 2*math.Pi && n < 3 {
+		n = 3
+	}
+	return n
+}
+
+// flattenArc returns points approximating an elliptical arc from p0 to p1,
+// described as in the SVG "A" path command, excluding p0. See:
+// https://www.w3.org/TR/SVG/implnote.html#ArcImplementationNotes
+func flattenArc(p0 polyclip.Point, rx, ry, rotation float64, large, sweep bool, p1 polyclip.Point, tolerance float64) []polyclip.Point {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if p0.Equals(p1) {
+		return nil
+	}
+	if rx == 0 || ry == 0 {
+		return []polyclip.Point{p1}
+	}
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+
+	// step 1: compute (x1', y1')
+	dx, dy := (p0.X-p1.X)/2, (p0.Y-p1.Y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+
+	// correct out-of-range radii
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+
+	// step 2: compute (cx', cy')
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	k := math.Sqrt(math.Max(num, 0) / den)
+	if large == sweep {
+		k = -k
+	}
+	cx1, cy1 := k*rx*y1/ry, -k*ry*x1/rx
+
+	// step 3: compute (cx, cy)
+	cx := cos*cx1 - sin*cy1 + (p0.X+p1.X)/2
+	cy := sin*cx1 + cos*cy1 + (p0.Y+p1.Y)/2
+
+	// step 4: compute angles
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	n := arcSegments(math.Max(rx, ry), delta, tolerance)
+	pts := make([]polyclip.Point, 0, n)
+	for i := 1; i < n; i++ {
+		a := theta + delta*float64(i)/float64(n)
+		x, y := rx*math.Cos(a), ry*math.Sin(a)
+		pts = append(pts, polyclip.Point{X: cos*x - sin*y + cx, Y: sin*x + cos*y + cy})
+	}
+	return append(pts, p1)
+}
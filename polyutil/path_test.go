@@ -0,0 +1,169 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// distToContour returns the distance from p to the boundary of c.
+func distToContour(p polyclip.Point, c polyclip.Contour) float64 {
+	d := math.Inf(1)
+	for i := range c {
+		d = math.Min(d, distToSegment(p, c[i], c[(i+1)%len(c)]))
+	}
+	return d
+}
+
+func TestPathLines(t *T) {
+	p := NewPath(0.1)
+	p.LineTo(polyclip.Point{X: 1, Y: 0})
+	p.LineTo(polyclip.Point{X: 1, Y: 0})
+	p.LineTo(polyclip.Point{X: 1, Y: 1})
+	p.Close()
+	verify(t, p.Current() == polyclip.Point{}, "Expected current point back at start, got %v", p.Current())
+	p.MoveTo(polyclip.Point{X: 5, Y: 5})
+	p.MoveTo(polyclip.Point{X: 2, Y: 2})
+	p.LineTo(polyclip.Point{X: 3, Y: 2})
+	p.LineTo(polyclip.Point{X: 3, Y: 3})
+	p.LineTo(polyclip.Point{X: 2, Y: 2})
+	expected := "[[{0 0} {1 0} {1 1}] [{2 2} {3 2} {3 3}]]"
+	verify(t, fmt.Sprint(p.Polygon()) == expected, "Expected %s, got %v", expected, p.Polygon())
+
+	// the unfinished subpath can still be extended
+	p.LineTo(polyclip.Point{X: 2, Y: 3})
+	expected = "[[{0 0} {1 0} {1 1}] [{2 2} {3 2} {3 3} {2 2} {2 3}]]"
+	verify(t, fmt.Sprint(p.Polygon()) == expected, "Expected %s, got %v", expected, p.Polygon())
+}
+
+func TestPathDegenerate(t *T) {
+	p := NewPath(0.1)
+	p.MoveTo(polyclip.Point{X: 1, Y: 1})
+	p.MoveTo(polyclip.Point{X: 2, Y: 2})
+	p.LineTo(polyclip.Point{X: 3, Y: 2})
+	p.Close()
+	p.LineTo(polyclip.Point{X: 3, Y: 3})
+	p.LineTo(polyclip.Point{X: 2, Y: 2})
+	p.LineTo(polyclip.Point{X: 3, Y: 3})
+	verify(t, len(p.Polygon()) == 0, "Expected no contours, got %v", p.Polygon())
+	p.MoveTo(polyclip.Point{X: 0, Y: 0})
+	verify(t, len(p.Polygon()) == 0, "Expected no contours, got %v", p.Polygon())
+
+	// a subpath becomes a contour once it gets a third distinct point
+	p.LineTo(polyclip.Point{X: 1, Y: 0})
+	p.LineTo(polyclip.Point{X: 0, Y: 0})
+	p.LineTo(polyclip.Point{X: 1, Y: 1})
+	expected := "[[{0 0} {1 0} {0 0} {1 1}]]"
+	verify(t, fmt.Sprint(p.Polygon()) == expected, "Expected %s, got %v", expected, p.Polygon())
+}
+
+func TestPathTolerance(t *T) {
+	for _, tolerance := range []float64{0, -1, math.NaN()} {
+		func() {
+			defer func() {
+				verify(t, recover() != nil, "Expected a panic for tolerance %v", tolerance)
+			}()
+			NewPath(tolerance)
+		}()
+	}
+	_, err := DecodeSVG(strings.NewReader(`<svg><circle cx="0" cy="0" r="1"/></svg>`), math.NaN())
+	verify(t, err != nil, "Expected an error for NaN tolerance")
+}
+
+func TestPathCurves(t *T) {
+	const tolerance = 0.01
+	cases := []struct {
+		build func(p *Path)
+		curve func(t float64) polyclip.Point
+	}{
+		// quadratic
+		{func(p *Path) { p.QuadTo(polyclip.Point{X: 1, Y: 2}, polyclip.Point{X: 2, Y: 0}) },
+			func(t float64) polyclip.Point { return polyclip.Point{X: 2 * t, Y: 4 * t * (1 - t)} }},
+		// cubic with an inflection
+		{func(p *Path) {
+			p.CubicTo(polyclip.Point{X: 1, Y: 3}, polyclip.Point{X: 2, Y: -3}, polyclip.Point{X: 3, Y: 0})
+		}, func(t float64) polyclip.Point {
+			s := 1 - t
+			return polyclip.Point{X: 3 * t, Y: 9*s*s*t - 9*s*t*t}
+		}},
+		// cubic overshooting both ends of its chord
+		{func(p *Path) {
+			p.CubicTo(polyclip.Point{X: 4, Y: 0}, polyclip.Point{X: -3, Y: 0}, polyclip.Point{X: 1, Y: 0})
+		}, func(t float64) polyclip.Point {
+			s := 1 - t
+			return polyclip.Point{X: 12*s*s*t - 9*s*t*t + t*t*t}
+		}},
+		// semicircle, counterclockwise from (1, 0)
+		{func(p *Path) {
+			p.MoveTo(polyclip.Point{X: 1, Y: 0})
+			p.ArcTo(1, 1, 0, false, true, polyclip.Point{X: -1, Y: 0})
+		}, func(t float64) polyclip.Point {
+			return polyclip.Point{X: math.Cos(math.Pi * t), Y: math.Sin(math.Pi * t)}
+		}},
+		// rotated elliptic arc, the larger part
+		{func(p *Path) {
+			p.MoveTo(polyclip.Point{X: 2, Y: 0})
+			p.ArcTo(2, 1, 0, true, true, polyclip.Point{X: 0, Y: -1})
+		}, func(t float64) polyclip.Point {
+			a := 1.5 * math.Pi * t
+			return polyclip.Point{X: 2 * math.Cos(a), Y: math.Sin(a)}
+		}},
+	}
+	for i, c := range cases {
+		p := NewPath(tolerance)
+		c.build(p)
+		poly := p.Polygon()
+		verify(t, len(poly) == 1 && len(poly[0]) < 100, "Case %d: expected a single, moderately sized contour, got %v", i, poly)
+		if len(poly) != 1 {
+			continue
+		}
+		// the contour is closed by a chord of the whole curve, which can't
+		// be helped; skip points near it
+		start, end := c.curve(0), c.curve(1)
+		for k := 0; k <= 1000; k++ {
+			pt := c.curve(float64(k) / 1000)
+			if distToSegment(pt, start, end) < tolerance {
+				continue
+			}
+			d := distToContour(pt, poly[0])
+			verify(t, d <= tolerance*(1+1e-9), "Case %d: curve point %v lies %v away from %v", i, pt, d, poly[0])
+		}
+	}
+}
+
+func TestPathConstruct(t *T) {
+	// a disc made of two arcs, cut by a square
+	p := NewPath(1e-4)
+	p.MoveTo(polyclip.Point{X: 1, Y: 0})
+	p.ArcTo(1, 1, 0, false, true, polyclip.Point{X: -1, Y: 0})
+	p.ArcTo(1, 1, 0, false, true, polyclip.Point{X: 1, Y: 0})
+	p.Close()
+	disc := p.Polygon()
+	verify(t, math.Abs(Area(disc)-math.Pi) < 1e-3, "Expected area of disc close to pi, got %v", Area(disc))
+
+	square := polyclip.Polygon{{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}, {X: 0, Y: 2}}}
+	quarter := disc.Construct(polyclip.INTERSECTION, square)
+	verify(t, math.Abs(Area(quarter)-math.Pi/4) < 1e-3, "Expected area of quarter close to pi/4, got %v", Area(quarter))
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"math"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// ringGroup is a single outer contour, together with the holes directly
+// inside it.
+type ringGroup struct {
+	outer polyclip.Contour
+	holes []polyclip.Contour
+}
+
+// groupRings finds out which contours of p are outer boundaries and which are
+// holes, based on how deeply they are nested in other contours: contours
+// nested at even depth are outer, and those at odd depth are holes of the
+// innermost contour containing them. Contours are assumed not to cross each
+// other, as is the case for results of polyclip.Polygon.Construct.
+func groupRings(p polyclip.Polygon) []ringGroup {
+	depth, parent := nesting(p)
+	groups := []ringGroup{}
+	index := map[int]int{} // index in groups of each outer contour
+	for i, c := range p {
+		if len(c) > 0 && depth[i]%2 == 0 {
+			index[i] = len(groups)
+			groups = append(groups, ringGroup{outer: c})
+		}
+	}
+	for i, c := range p {
+		if depth[i]%2 == 1 {
+			if g, ok := index[parent[i]]; ok {
+				groups[g].holes = append(groups[g].holes, c)
+			}
+		}
+	}
+	return groups
+}
+
+// nesting returns, for each contour of p, the number of other contours
+// containing it, and the index of the innermost of them (or -1).
+func nesting(p polyclip.Polygon) (depth, parent []int) {
+	depth = make([]int, len(p))
+	parent = make([]int, len(p))
+	bboxes := make([]polyclip.Rectangle, len(p))
+	for i, c := range p {
+		if len(c) > 0 {
+			bboxes[i] = c.BoundingBox()
+		}
+	}
+	for i, c := range p {
+		parent[i] = -1
+		if len(c) == 0 {
+			continue
+		}
+		for j, other := range p {
+			if i == j || len(other) < 3 || !within(bboxes[i], bboxes[j]) {
+				continue
+			}
+			pt, ok := samplePoint(c, other)
+			if !ok || !other.Contains(pt) {
+				continue
+			}
+			depth[i]++
+			// the innermost container has the smallest area
+			if parent[i] == -1 || math.Abs(contourArea(other)) < math.Abs(contourArea(p[parent[i]])) {
+				parent[i] = j
+			}
+		}
+	}
+	return depth, parent
+}
+
+// within reports whether r lies inside (or on the boundary of) outer
+func within(r, outer polyclip.Rectangle) bool {
+	return outer.Min.X <= r.Min.X && r.Max.X <= outer.Max.X &&
+		outer.Min.Y <= r.Min.Y && r.Max.Y <= outer.Max.Y
+}
+
+// samplePoint returns a point of contour c (a vertex or middle of an edge)
+// which does not lie on the boundary of other; ok is false if there's no such
+// point.
+func samplePoint(c, other polyclip.Contour) (p polyclip.Point, ok bool) {
+	for i := range c {
+		next := c[(i+1)%len(c)]
+		for _, p := range []polyclip.Point{c[i], {X: (c[i].X + next.X) / 2, Y: (c[i].Y + next.Y) / 2}} {
+			if !onBoundary(p, other) {
+				return p, true
+			}
+		}
+	}
+	return polyclip.Point{}, false
+}
+
+func onBoundary(p polyclip.Point, c polyclip.Contour) bool {
+	for i := range c {
+		a, b := c[i], c[(i+1)%len(c)]
+		if (b.X-a.X)*(p.Y-a.Y)-(b.Y-a.Y)*(p.X-a.X) != 0 {
+			continue
+		}
+		if math.Min(a.X, b.X) <= p.X && p.X <= math.Max(a.X, b.X) &&
+			math.Min(a.Y, b.Y) <= p.Y && p.Y <= math.Max(a.Y, b.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// contourArea returns the signed area of a contour; it's positive if the
+// contour is counter-clockwise in a coordinate system with Y axis going up.
+func contourArea(c polyclip.Contour) float64 {
+	a := 0.0
+	for i := range c {
+		p, q := c[i], c[(i+1)%len(c)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
+
+// oriented returns c, or its reversed copy, so that its signed area has the
+// specified sign.
+func oriented(c polyclip.Contour, counterClockwise bool) polyclip.Contour {
+	if (contourArea(c) >= 0) == counterClockwise {
+		return c
+	}
+	r := make(polyclip.Contour, len(c))
+	for i := range c {
+		r[len(c)-1-i] = c[i]
+	}
+	return r
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// Shrink tries to find a smaller pair of polygons for which fails still
+// returns true, to simplify reporting and debugging problems found on large
+// inputs. It repeatedly removes whole contours and single vertices, and
+// rounds coordinates, keeping each change only if fails(subject, clipping)
+// still holds, until no more changes are possible. The input polygons are not
+// modified. If fails does not hold for the input polygons, they are returned
+// unchanged.
+func Shrink(subject, clipping polyclip.Polygon, fails func(subject, clipping polyclip.Polygon) bool) (polyclip.Polygon, polyclip.Polygon) {
+	polys := [2]polyclip.Polygon{subject.Clone(), clipping.Clone()}
+	if !fails(polys[0], polys[1]) {
+		return polys[0], polys[1]
+	}
+	try := func(i int, candidate polyclip.Polygon) bool {
+		next := polys
+		next[i] = candidate
+		if !fails(next[0], next[1]) {
+			return false
+		}
+		polys = next
+		return true
+	}
+
+	for progress := true; progress; {
+		progress = false
+
+		// remove contours
+		for i := range polys {
+			for j := 0; j < len(polys[i]); {
+				candidate := append(polys[i][:j:j], polys[i][j+1:]...)
+				if try(i, candidate) {
+					progress = true
+					continue
+				}
+				j++
+			}
+		}
+
+		// remove vertices
+		for i := range polys {
+			for j := range polys[i] {
+				for k := 0; k < len(polys[i][j]) && len(polys[i][j]) > 3; {
+					c := polys[i][j]
+					candidate := polys[i].Clone()
+					candidate[j] = append(c[:k:k], c[k+1:]...)
+					if try(i, candidate) {
+						progress = true
+						continue
+					}
+					k++
+				}
+			}
+		}
+
+		// round coordinates, first all at once, then each vertex separately
+		for digits := 0; digits <= 6; digits++ {
+			next := [2]polyclip.Polygon{round(polys[0], digits, -1, -1), round(polys[1], digits, -1, -1)}
+			if samePoints(next[0], polys[0]) && samePoints(next[1], polys[1]) {
+				break // already rounded
+			}
+			if fails(next[0], next[1]) {
+				polys = next
+				progress = true
+				break
+			}
+		}
+		for i := range polys {
+			for j := range polys[i] {
+				for k := range polys[i][j] {
+					for digits := 0; digits <= 6; digits++ {
+						candidate := round(polys[i], digits, j, k)
+						if candidate[j][k].Equals(polys[i][j][k]) {
+							break
+						}
+						if try(i, candidate) {
+							progress = true
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+	return polys[0], polys[1]
+}
+
+// round returns a copy of p with coordinates rounded to the specified number
+// of decimal digits; only the vertex k of contour j is rounded, unless j < 0.
+func round(p polyclip.Polygon, digits int, j, k int) polyclip.Polygon {
+	scale := math.Pow(10, float64(digits))
+	r := func(f float64) float64 { return math.Round(f*scale) / scale }
+	p = p.Clone()
+	for jj := range p {
+		for kk := range p[jj] {
+			if j < 0 || jj == j && kk == k {
+				p[jj][kk] = polyclip.Point{X: r(p[jj][kk].X), Y: r(p[jj][kk].Y)}
+			}
+		}
+	}
+	return p
+}
+
+// samePoints reports whether p and q have the same contours and vertices
+func samePoints(p, q polyclip.Polygon) bool {
+	if len(p) != len(q) {
+		return false
+	}
+	for j := range p {
+		if len(p[j]) != len(q[j]) {
+			return false
+		}
+		for k := range p[j] {
+			if !p[j][k].Equals(q[j][k]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GoLiteral formats p as a Go expression of type polyclip.Polygon, with
+// coordinates written so that they can be read back without loss.
+func GoLiteral(p polyclip.Polygon) string {
+	f := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	buf := &bytes.Buffer{}
+	buf.WriteString("polyclip.Polygon{")
+	for i, c := range p {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString("{")
+		for j, pt := range c {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(buf, "{%s, %s}", f(pt.X), f(pt.Y))
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
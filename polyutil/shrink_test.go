@@ -0,0 +1,103 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"math"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestShrink(t *T) {
+	subject := polyclip.Polygon{
+		{{X: 0, Y: 0}, {X: 0, Y: 5}, {X: 5, Y: 5}, {X: 5, Y: 0}},
+		{{X: 10.123, Y: 10.456}, {X: 10.5, Y: 20}, {X: 20, Y: 20}, {X: 20, Y: 10}, {X: 15, Y: 9}},
+	}
+	clipping := polyclip.Polygon{
+		{{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 2, Y: 2}},
+		{{X: 11, Y: 11}, {X: 11, Y: 12}, {X: 12, Y: 12}},
+	}
+	// "fails" as long as some subject vertex lies right of x=10.1, and the
+	// clipping polygon isn't empty
+	fails := func(subject, clipping polyclip.Polygon) bool {
+		if len(clipping) == 0 {
+			return false
+		}
+		for _, c := range subject {
+			for _, p := range c {
+				if p.X > 10.1 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	s, c := Shrink(subject, clipping, fails)
+	verify(t, fails(s, c), "Expected shrunk polygons still failing, got: %v %v", s, c)
+	verify(t, len(s) == 1 && len(s[0]) == 3, "Expected single triangle in subject, got: %v", s)
+	verify(t, len(c) == 1, "Expected single contour in clipping, got: %v", c)
+	for _, p := range append(s[0], c[0]...) {
+		verify(t, p.X == math.Round(p.X) && p.Y == math.Round(p.Y), "Expected coordinates rounded to integers, got: %v %v", s, c)
+	}
+	verify(t, subject[1][0].X == 10.123, "Expected input not modified, got: %v", subject)
+
+	s, c = Shrink(subject, clipping, func(s, c polyclip.Polygon) bool { return false })
+	verify(t, len(s) == 2 && len(c) == 2, "Expected unchanged polygons, got: %v %v", s, c)
+}
+
+func TestShrinkAfterRounding(t *T) {
+	subject := polyclip.Polygon{
+		{{X: 0.2, Y: 0.1}, {X: 5.1, Y: 0.3}, {X: 5.2, Y: 5.1}, {X: 2.4, Y: 7.3}, {X: 0.1, Y: 4.8}},
+	}
+	// vertices can only be removed once all the coordinates are integers
+	fails := func(subject, clipping polyclip.Polygon) bool {
+		if len(subject) != 1 || len(subject[0]) < 3 {
+			return false
+		}
+		for _, p := range subject[0] {
+			if p.X != math.Round(p.X) || p.Y != math.Round(p.Y) {
+				return len(subject[0]) == 5
+			}
+		}
+		return true
+	}
+
+	s, _ := Shrink(subject, polyclip.Polygon{}, fails)
+	verify(t, len(s) == 1 && len(s[0]) == 3, "Expected a triangle, got: %v", s)
+}
+
+func TestGoLiteral(t *T) {
+	cases := []struct {
+		p      polyclip.Polygon
+		result string
+	}{
+		{polyclip.Polygon{}, "polyclip.Polygon{}"},
+		{
+			polyclip.Polygon{{{X: 1, Y: 2}, {X: 0.1, Y: -3e-20}, {X: 1.427255375e+06, Y: 5}}, {{X: 0, Y: 0}}},
+			"polyclip.Polygon{{{1, 2}, {0.1, -3e-20}, {1.427255375e+06, 5}}, {{0, 0}}}",
+		},
+	}
+	for i, c := range cases {
+		result := GoLiteral(c.p)
+		verify(t, result == c.result, "Case %d: expected %s, got %s", i, c.result, result)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"math"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// Area returns the area of the region described by p, i.e. covered by an odd
+// number of its contours, as in polyclip. The contours are assumed not to
+// cross each other, as is the case for results of polyclip.Polygon.Construct:
+// areas of holes are subtracted from the areas of contours containing them.
+func Area(p polyclip.Polygon) float64 {
+	depth, _ := nesting(p)
+	a := 0.0
+	for i, c := range p {
+		if depth[i]%2 == 0 {
+			a += math.Abs(contourArea(c))
+		} else {
+			a -= math.Abs(contourArea(c))
+		}
+	}
+	return a
+}
+
+// Simplify reduces the number of vertices of p with the Ramer-Douglas-Peucker
+// algorithm: a vertex is removed if it's closer than tolerance to the segment
+// between the nearest vertices kept on both sides. Contours reduced to less
+// than 3 vertices are dropped. The polygon is not modified. Note that
+// simplified contours may intersect themselves or each other.
+func Simplify(p polyclip.Polygon, tolerance float64) polyclip.Polygon {
+	result := polyclip.Polygon{}
+	for _, c := range p {
+		if len(c) < 3 {
+			continue
+		}
+		// split the closed contour at the first vertex and the one
+		// farthest from it, simplifying both halves separately
+		far, dist := 0, -1.0
+		for i, pt := range c {
+			d := polyclip.Point{X: pt.X - c[0].X, Y: pt.Y - c[0].Y}.Length()
+			if d > dist {
+				far, dist = i, d
+			}
+		}
+		keep := make([]bool, len(c))
+		keep[0], keep[far] = true, true
+		closed := append(c[:len(c):len(c)], c[0])
+		simplifyRange(closed, 0, far, tolerance, keep)
+		simplifyRange(closed, far, len(c), tolerance, keep)
+
+		s := polyclip.Contour{}
+		for i, pt := range c {
+			if keep[i] {
+				s.Add(pt)
+			}
+		}
+		if len(s) >= 3 {
+			result.Add(s)
+		}
+	}
+	return result
+}
+
+// simplifyRange marks in keep the vertices of c between i and j (exclusive)
+// which are needed to approximate c within tolerance. keep is indexed modulo
+// its length, so that j may point to the closing copy of the first vertex.
+func simplifyRange(c polyclip.Contour, i, j int, tolerance float64, keep []bool) {
+	if j-i < 2 {
+		return
+	}
+	far, dist := -1, tolerance
+	for k := i + 1; k < j; k++ {
+		if d := distToSegment(c[k], c[i], c[j]); d > dist {
+			far, dist = k, d
+		}
+	}
+	if far < 0 {
+		return
+	}
+	keep[far%len(keep)] = true
+	simplifyRange(c, i, far, tolerance, keep)
+	simplifyRange(c, far, j, tolerance, keep)
+}
+
+// distToSegment returns the distance from p to the segment between a and b.
+func distToSegment(p, a, b polyclip.Point) float64 {
+	d := polyclip.Point{X: b.X - a.X, Y: b.Y - a.Y}
+	l2 := d.X*d.X + d.Y*d.Y
+	t := 0.0
+	if l2 > 0 {
+		t = math.Max(0, math.Min(1, ((p.X-a.X)*d.X+(p.Y-a.Y)*d.Y)/l2))
+	}
+	return polyclip.Point{X: p.X - a.X - t*d.X, Y: p.Y - a.Y - t*d.Y}.Length()
+}
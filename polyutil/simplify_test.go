@@ -0,0 +1,60 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"fmt"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestArea(t *T) {
+	outer := polyclip.Contour{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 10, Y: 10}, {X: 10, Y: 0}}
+	hole := polyclip.Contour{{X: 2, Y: 2}, {X: 8, Y: 2}, {X: 8, Y: 8}, {X: 2, Y: 8}}
+	island := polyclip.Contour{{X: 4, Y: 4}, {X: 6, Y: 4}, {X: 6, Y: 6}}
+	cases := []struct {
+		p        polyclip.Polygon
+		expected float64
+	}{
+		{polyclip.Polygon{}, 0},
+		{polyclip.Polygon{outer}, 100},
+		{polyclip.Polygon{outer, hole}, 64},
+		{polyclip.Polygon{hole, island, outer}, 66},
+	}
+	for i, c := range cases {
+		a := Area(c.p)
+		verify(t, a == c.expected, "Case %d: expected %v, got: %v", i, c.expected, a)
+	}
+}
+
+func TestSimplify(t *T) {
+	// a square with extra vertices almost on its edges
+	p := polyclip.Polygon{
+		{{X: 0, Y: 0}, {X: 5, Y: 0.05}, {X: 10, Y: 0}, {X: 10, Y: 5}, {X: 10, Y: 10}, {X: 5, Y: 10.2}, {X: 0, Y: 10}, {X: -0.01, Y: 3}},
+		{{X: 20, Y: 20}, {X: 20.05, Y: 20}, {X: 20.05, Y: 20.05}},
+	}
+	s := Simplify(p, 0.1)
+	verify(t, fmt.Sprint(s) == "[[{0 0} {10 0} {10 10} {5 10.2} {0 10}]]", "Got: %v", s)
+	verify(t, len(p[0]) == 8, "Expected input not modified, got: %v", p)
+
+	s = Simplify(p, 0)
+	verify(t, len(s) == 2 && len(s[0]) == 7, "Expected only the collinear vertex removed, got: %v", s)
+}
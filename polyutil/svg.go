@@ -0,0 +1,439 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// SVGLayer describes a polygon to be written by EncodeSVG.
+type SVGLayer struct {
+	Polygon polyclip.Polygon
+	Rule    FillRule
+	// Style is an optional value of the "style" attribute of the path, e.g.
+	// "fill:red;stroke:black".
+	Style string
+}
+
+// EncodeSVG writes an SVG image, with each layer written as a single <path>
+// element, with a "fill-rule" attribute according to its Rule. The
+// "viewBox" of the image is set to the bounding box of all layers.
+func EncodeSVG(w io.Writer, layers ...SVGLayer) error {
+	var bb *polyclip.Rectangle
+	for _, l := range layers {
+		for _, c := range l.Polygon {
+			if len(c) == 0 {
+				continue
+			}
+			cbb := c.BoundingBox()
+			if bb == nil {
+				bb = &cbb
+				continue
+			}
+			bb.Min.X, bb.Min.Y = math.Min(bb.Min.X, cbb.Min.X), math.Min(bb.Min.Y, cbb.Min.Y)
+			bb.Max.X, bb.Max.Y = math.Max(bb.Max.X, cbb.Max.X), math.Max(bb.Max.Y, cbb.Max.Y)
+		}
+	}
+	if bb == nil {
+		bb = &polyclip.Rectangle{}
+	}
+
+	_, err := fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"%s %s %s %s\">\n",
+		svgNum(bb.Min.X), svgNum(bb.Min.Y), svgNum(bb.Max.X-bb.Min.X), svgNum(bb.Max.Y-bb.Min.Y))
+	if err != nil {
+		return err
+	}
+	for _, l := range layers {
+		d := []string{}
+		for _, c := range l.Polygon {
+			for i, p := range c {
+				cmd := "L"
+				if i == 0 {
+					cmd = "M"
+				}
+				d = append(d, cmd+svgNum(p.X)+" "+svgNum(p.Y))
+			}
+			if len(c) > 0 {
+				d = append(d, "Z")
+			}
+		}
+		rule := "evenodd"
+		if l.Rule == NonZero {
+			rule = "nonzero"
+		}
+		_, err = fmt.Fprintf(w, "  <path fill-rule=\"%s\"", rule)
+		if err != nil {
+			return err
+		}
+		if l.Style != "" {
+			_, err = fmt.Fprint(w, " style=\"")
+			if err == nil {
+				err = xml.EscapeText(w, []byte(l.Style))
+			}
+			if err == nil {
+				_, err = fmt.Fprint(w, "\"")
+			}
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(w, " d=\"%s\"/>\n", strings.Join(d, " "))
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprint(w, "</svg>\n")
+	return err
+}
+
+func svgNum(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// DecodeSVG reads the shapes from an SVG image. Each <polygon>, <rect>,
+// <circle>, <ellipse> and <path> element becomes a separate polygon, with
+// each subpath of a path becoming a contour. Curves and arcs in paths, as well
+// as circles and ellipses, are flattened into line segments deviating from the
+// exact shape by no more than tolerance. Transforms, styles (including
+// "fill-rule") and rounded corners of rectangles are ignored.
+func DecodeSVG(r io.Reader, tolerance float64) ([]polyclip.Polygon, error) {
+	if !(tolerance > 0) {
+		return nil, fmt.Errorf("polyutil: SVG flattening tolerance must be positive, got %v", tolerance)
+	}
+	d := xml.NewDecoder(r)
+	polys := []polyclip.Polygon{}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return polys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		attrs := map[string]string{}
+		for _, a := range el.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+		var p polyclip.Polygon
+		switch el.Name.Local {
+		case "polygon":
+			p, err = svgPolygon(attrs["points"])
+		case "rect":
+			p, err = svgRect(attrs)
+		case "circle":
+			attrs["rx"], attrs["ry"] = attrs["r"], attrs["r"]
+			p, err = svgEllipse(attrs, tolerance)
+		case "ellipse":
+			p, err = svgEllipse(attrs, tolerance)
+		case "path":
+			p, err = svgPath(attrs["d"], tolerance)
+		default:
+			continue
+		}
+		if err != nil {
+			line, _ := d.InputPos()
+			return nil, fmt.Errorf("polyutil: SVG line %d: <%s>: %v", line, el.Name.Local, err)
+		}
+		polys = append(polys, p)
+	}
+}
+
+func svgAttrs(attrs map[string]string, names ...string) ([]float64, error) {
+	values := make([]float64, len(names))
+	for i, n := range names {
+		s, ok := attrs[n]
+		if !ok {
+			continue // missing attributes default to 0
+		}
+		var err error
+		values[i], err = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "px"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %v", n, err)
+		}
+	}
+	return values, nil
+}
+
+func svgPolygon(points string) (polyclip.Polygon, error) {
+	s := &svgScanner{s: points}
+	c := polyclip.Contour{}
+	for s.more() {
+		x, err := s.number()
+		if err != nil {
+			return nil, err
+		}
+		y, err := s.number()
+		if err != nil {
+			return nil, err
+		}
+		c.Add(polyclip.Point{X: x, Y: y})
+	}
+	return polyclip.Polygon{c}, nil
+}
+
+func svgRect(attrs map[string]string) (polyclip.Polygon, error) {
+	v, err := svgAttrs(attrs, "x", "y", "width", "height")
+	if err != nil {
+		return nil, err
+	}
+	x, y, w, h := v[0], v[1], v[2], v[3]
+	return polyclip.Polygon{{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}}}, nil
+}
+
+func svgEllipse(attrs map[string]string, tolerance float64) (polyclip.Polygon, error) {
+	v, err := svgAttrs(attrs, "cx", "cy", "rx", "ry")
+	if err != nil {
+		return nil, err
+	}
+	cx, cy, rx, ry := v[0], v[1], v[2], v[3]
+	c := polyclip.Contour{}
+	n := arcSegments(math.Max(rx, ry), 2*math.Pi, tolerance)
+	for i := 0; i < n; i++ {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		c.Add(polyclip.Point{X: cx + rx*math.Cos(a), Y: cy + ry*math.Sin(a)})
+	}
+	return polyclip.Polygon{c}, nil
+}
+
+// svgScanner splits SVG path data and point lists into commands and numbers
+type svgScanner struct {
+	s string
+}
+
+func (s *svgScanner) skip() {
+	s.s = strings.TrimLeft(s.s, " \t\r\n,")
+}
+
+func (s *svgScanner) more() bool {
+	s.skip()
+	return s.s != ""
+}
+
+// command returns the next command letter, or 0 if next is a number
+func (s *svgScanner) command() byte {
+	s.skip()
+	if s.s == "" {
+		return 0
+	}
+	c := s.s[0]
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+		if c != 'e' && c != 'E' {
+			s.s = s.s[1:]
+			return c
+		}
+	}
+	return 0
+}
+
+func (s *svgScanner) number() (float64, error) {
+	s.skip()
+	i := 0
+	if i < len(s.s) && (s.s[i] == '+' || s.s[i] == '-') {
+		i++
+	}
+	digits, dot := false, false
+	for ; i < len(s.s); i++ {
+		c := s.s[i]
+		if c >= '0' && c <= '9' {
+			digits = true
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+	}
+	if digits && i < len(s.s) && (s.s[i] == 'e' || s.s[i] == 'E') {
+		j := i + 1
+		if j < len(s.s) && (s.s[j] == '+' || s.s[j] == '-') {
+			j++
+		}
+		if j < len(s.s) && s.s[j] >= '0' && s.s[j] <= '9' {
+			for j < len(s.s) && s.s[j] >= '0' && s.s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	if !digits {
+		if s.s == "" {
+			return 0, fmt.Errorf("unexpected end of data")
+		}
+		return 0, fmt.Errorf("expected number at %q", s.s)
+	}
+	f, err := strconv.ParseFloat(s.s[:i], 64)
+	s.s = s.s[i:]
+	return f, err
+}
+
+func (s *svgScanner) flag() (bool, error) {
+	s.skip()
+	if s.s != "" && (s.s[0] == '0' || s.s[0] == '1') {
+		f := s.s[0] == '1'
+		s.s = s.s[1:]
+		return f, nil
+	}
+	return false, fmt.Errorf("expected flag at %q", s.s)
+}
+
+func (s *svgScanner) numbers(n int) ([]float64, error) {
+	v := make([]float64, n)
+	for i := range v {
+		var err error
+		v[i], err = s.number()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func svgPath(data string, tolerance float64) (polyclip.Polygon, error) {
+	s := &svgScanner{s: data}
+	path := NewPath(tolerance)
+	var ctrl polyclip.Point // the last control point, for S and T
+	var cmd, last byte
+
+	for s.more() {
+		if next := s.command(); next != 0 {
+			cmd = next
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("expected command at %q", s.s)
+		}
+		rel := cmd >= 'a'
+		cur := path.Current()
+		abs := func(x, y float64) polyclip.Point {
+			if rel {
+				return polyclip.Point{X: cur.X + x, Y: cur.Y + y}
+			}
+			return polyclip.Point{X: x, Y: y}
+		}
+
+		upper := cmd &^ 0x20
+		var v []float64
+		var err error
+		switch upper {
+		case 'Z':
+			path.Close()
+			last, cmd = 'Z', 0
+			continue
+		case 'M', 'L', 'T':
+			v, err = s.numbers(2)
+		case 'H', 'V':
+			v, err = s.numbers(1)
+		case 'S', 'Q':
+			v, err = s.numbers(4)
+		case 'C':
+			v, err = s.numbers(6)
+		case 'A':
+			v, err = s.numbers(3)
+			var large, sweep bool
+			if err == nil {
+				large, err = s.flag()
+			}
+			if err == nil {
+				sweep, err = s.flag()
+			}
+			var end []float64
+			if err == nil {
+				end, err = s.numbers(2)
+			}
+			if err != nil {
+				return nil, err
+			}
+			path.ArcTo(v[0], v[1], v[2], large, sweep, abs(end[0], end[1]))
+			last = 'A'
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported path command %q", cmd)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch upper {
+		case 'M':
+			path.MoveTo(abs(v[0], v[1]))
+			// subsequent pairs of coordinates are implicit lineto commands
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'L':
+			path.LineTo(abs(v[0], v[1]))
+		case 'H':
+			p := polyclip.Point{X: v[0], Y: cur.Y}
+			if rel {
+				p.X += cur.X
+			}
+			path.LineTo(p)
+		case 'V':
+			p := polyclip.Point{X: cur.X, Y: v[0]}
+			if rel {
+				p.Y += cur.Y
+			}
+			path.LineTo(p)
+		case 'C', 'S':
+			var c1 polyclip.Point
+			if upper == 'S' {
+				c1 = reflect(ctrl, cur, last == 'C' || last == 'S')
+				v = append([]float64{0, 0}, v...)
+			} else {
+				c1 = abs(v[0], v[1])
+			}
+			c2, end := abs(v[2], v[3]), abs(v[4], v[5])
+			path.CubicTo(c1, c2, end)
+			ctrl = c2
+		case 'Q', 'T':
+			var c1 polyclip.Point
+			if upper == 'T' {
+				c1 = reflect(ctrl, cur, last == 'Q' || last == 'T')
+				v = append([]float64{0, 0}, v...)
+			} else {
+				c1 = abs(v[0], v[1])
+			}
+			end := abs(v[2], v[3])
+			path.QuadTo(c1, end)
+			ctrl = c1
+		}
+		last = upper
+	}
+	return path.Polygon(), nil
+}
+
+// reflect returns the reflection of control point ctrl about p, as needed by
+// the "smooth" curve commands; or p if the previous command was not a curve.
+func reflect(ctrl, p polyclip.Point, curve bool) polyclip.Point {
+	if !curve {
+		return p
+	}
+	return polyclip.Point{X: 2*p.X - ctrl.X, Y: 2*p.Y - ctrl.Y}
+}
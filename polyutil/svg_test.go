@@ -0,0 +1,125 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestEncodeSVG(t *T) {
+	buf := &bytes.Buffer{}
+	err := EncodeSVG(buf,
+		SVGLayer{Polygon: polyclip.Polygon{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}, {{X: 2, Y: 2}, {X: 3, Y: 2}, {X: 3, Y: 3.5}}}},
+		SVGLayer{Polygon: polyclip.Polygon{{{X: -1, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: 0}}}, Rule: NonZero, Style: "fill:red;stroke:\"blue\""})
+	verify(t, err == nil, "Expected no error encoding, got: %v", err)
+	expected := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="-1 0 4 3.5">
+  <path fill-rule="evenodd" d="M0 0 L1 0 L1 1 Z M2 2 L3 2 L3 3.5 Z"/>
+  <path fill-rule="nonzero" style="fill:red;stroke:&#34;blue&#34;" d="M-1 0 L0 1 L0 0 Z"/>
+</svg>
+`
+	verify(t, buf.String() == expected, "Expected:\n%s\ngot:\n%s", expected, buf)
+
+	polys, err := DecodeSVG(buf, 0.01)
+	verify(t, err == nil, "Expected no error decoding, got: %v", err)
+	verify(t, fmt.Sprint(polys) == "[[[{0 0} {1 0} {1 1}] [{2 2} {3 2} {3 3.5}]] [[{-1 0} {0 1} {0 0}]]]", "Expected round-trip, got: %v", polys)
+}
+
+func TestDecodeSVG(t *T) {
+	cases := []struct {
+		svg    string
+		result string
+	}{
+		{`<svg><polygon points="0,0 1,0 1,1"/></svg>`, "[[[{0 0} {1 0} {1 1}]]]"},
+		{`<svg><g><rect x="1" y="2" width="3" height="4"/></g></svg>`, "[[[{1 2} {4 2} {4 6} {1 6}]]]"},
+		{`<svg><path d="M1,1 h2 v2 H1 z m1 -1 l1-1 1 1Z"/></svg>`, "[[[{1 1} {3 1} {3 3} {1 3}] [{2 0} {3 -1} {4 0}]]]"},
+		{`<svg><path d="M0 0L1.5.5-1e1 2"/></svg>`, "[[[{0 0} {1.5 0.5} {-10 2}]]]"},
+		{`<svg><path d="M0 0 L0 0 L1 0 L1 1 L0 0"/></svg>`, "[[[{0 0} {1 0} {1 1}]]]"},
+	}
+	for i, c := range cases {
+		polys, err := DecodeSVG(strings.NewReader(c.svg), 0.01)
+		verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+		verify(t, fmt.Sprint(polys) == c.result, "Case %d: expected %s, got %v", i, c.result, polys)
+	}
+
+	errors := []string{
+		`<svg><path d="1 1"/></svg>`,
+		`<svg><path d="M1 1 L2"/></svg>`,
+		`<svg><path d="M1 1 X2 2"/></svg>`,
+		`<svg><rect x="a"/></svg>`,
+		`<svg><polygon points="1 2 3"/></svg>`,
+	}
+	for i, svg := range errors {
+		_, err := DecodeSVG(strings.NewReader(svg), 0.01)
+		verify(t, err != nil, "Error case %d: expected error, got none", i)
+	}
+}
+
+func TestDecodeSVGCurves(t *T) {
+	const tolerance = 0.001
+	onCircle := func(p polyclip.Point, cx, cy, r float64) bool {
+		return math.Abs(polyclip.Point{X: p.X - cx, Y: p.Y - cy}.Length()-r) <= tolerance
+	}
+	cases := []struct {
+		svg      string
+		cx, cy   float64
+		r        float64
+		minAngle float64 // of the arc, for checking the number of segments
+	}{
+		{`<circle cx="1" cy="2" r="3"/>`, 1, 2, 3, 2 * math.Pi},
+		{`<path d="M0 0 A 1 1 0 0 1 2 0 Z"/>`, 1, 0, 1, math.Pi},
+		{`<path d="M0 0 a 1 1 0 1 0 2 0"/>`, 1, 0, 1, math.Pi},
+		{`<path d="M0 0 A 0.5 0.5 0 0 1 2 0"/>`, 1, 0, 1, math.Pi}, // radius too small, scaled up
+	}
+	for i, c := range cases {
+		polys, err := DecodeSVG(strings.NewReader("<svg>"+c.svg+"</svg>"), tolerance)
+		verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+		if err != nil {
+			continue
+		}
+		pts := polys[0][0]
+		for _, p := range pts {
+			verify(t, onCircle(p, c.cx, c.cy, c.r), "Case %d: expected points on circle, got: %v", i, p)
+		}
+		verify(t, len(pts) >= arcSegments(c.r, c.minAngle, tolerance), "Case %d: expected enough segments, got %d", i, len(pts))
+	}
+
+	// the point at t=0.5 of a symmetric cubic curve must be reached within tolerance
+	polys, err := DecodeSVG(strings.NewReader(`<svg><path d="M0 0 C0 4 4 4 4 0 Q2 -4 0 0"/></svg>`), tolerance)
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	maxY, minY := 0.0, 0.0
+	for _, p := range polys[0][0] {
+		maxY, minY = math.Max(maxY, p.Y), math.Min(minY, p.Y)
+	}
+	verify(t, math.Abs(maxY-3) <= tolerance, "Expected cubic curve reaching y=3, got %v", maxY)
+	verify(t, math.Abs(minY+2) <= tolerance, "Expected quadratic curve reaching y=-2, got %v", minY)
+
+	// a cubic curve going beyond both ends of its chord, along it
+	polys, err = DecodeSVG(strings.NewReader(`<svg><path d="M0 0 C4 0 -3 0 1 0 L1 1 L0 1 Z"/></svg>`), tolerance)
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	bb := polys[0][0].BoundingBox()
+	verify(t, math.Abs(bb.Max.X-1.28335) <= tolerance, "Expected cubic curve reaching x=1.28335, got %v", bb.Max.X)
+	verify(t, math.Abs(bb.Min.X+0.28335) <= tolerance, "Expected cubic curve reaching x=-0.28335, got %v", bb.Min.X)
+}
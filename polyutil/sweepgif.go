@@ -0,0 +1,171 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"math"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// palette indexes used for rendering the sweep
+const (
+	sweepBackground = iota
+	sweepSubject
+	sweepClipping
+	sweepLine
+	sweepOutput
+	sweepDivided
+	sweepEdgeNormal // followed by colors for the other polyclip.EdgeKind values
+)
+
+var sweepPalette = color.Palette{
+	sweepBackground: color.Black,
+	sweepSubject:    color.RGBA{0, 0, 0x80, 0xff},
+	sweepClipping:   color.RGBA{0x80, 0, 0, 0xff},
+	sweepLine:       color.RGBA{0x60, 0x60, 0x60, 0xff},
+	sweepOutput:     color.White,
+	sweepDivided:    color.RGBA{0xff, 0xff, 0, 0xff},
+	sweepEdgeNormal + int(polyclip.EdgeNormal):              color.RGBA{0, 0xff, 0, 0xff},
+	sweepEdgeNormal + int(polyclip.EdgeNonContributing):     color.RGBA{0x80, 0x80, 0x80, 0xff},
+	sweepEdgeNormal + int(polyclip.EdgeSameTransition):      color.RGBA{0, 0xff, 0xff, 0xff},
+	sweepEdgeNormal + int(polyclip.EdgeDifferentTransition): color.RGBA{0xff, 0, 0xff, 0xff},
+}
+
+// EncodeSweepGIF renders an animation of the sweep performed when computing
+// (subject <operation> clipping), and writes it to w as an animated GIF.
+// Each frame shows the state after processing a single event: the outlines of
+// the input polygons, the position of the sweep line, the edges currently in
+// the sweep line (colored by their polyclip.EdgeKind), the freshly found
+// intersection points, and the edges already passed to the result.
+// The polygons are scaled to fit in a size x size image.
+func EncodeSweepGIF(w io.Writer, subject polyclip.Polygon, operation polyclip.Op, clipping polyclip.Polygon, size int) error {
+	steps := []polyclip.SweepStep{}
+	subject.ConstructTraced(operation, clipping, func(s polyclip.SweepStep) {
+		steps = append(steps, s)
+	})
+
+	bb := polyclip.Rectangle{
+		Min: polyclip.Point{X: math.Inf(1), Y: math.Inf(1)},
+		Max: polyclip.Point{X: math.Inf(-1), Y: math.Inf(-1)},
+	}
+	for _, p := range []polyclip.Polygon{subject, clipping} {
+		for _, c := range p {
+			cbb := c.BoundingBox()
+			bb.Min.X, bb.Min.Y = math.Min(bb.Min.X, cbb.Min.X), math.Min(bb.Min.Y, cbb.Min.Y)
+			bb.Max.X, bb.Max.Y = math.Max(bb.Max.X, cbb.Max.X), math.Max(bb.Max.Y, cbb.Max.Y)
+		}
+	}
+	v := newViewport(bb, size)
+
+	if len(steps) == 0 {
+		// trivial case, resolved without sweeping; show just the inputs
+		steps = append(steps, polyclip.SweepStep{Event: polyclip.Point{X: math.Inf(-1)}})
+	}
+
+	anim := &gif.GIF{}
+	var output []polyclip.TraceEdge
+	for _, s := range steps {
+		img := image.NewPaletted(image.Rect(0, 0, size, size), sweepPalette)
+		for _, c := range subject {
+			DrawPolyline(v.contour(c), indexBrush(img, sweepSubject))
+		}
+		for _, c := range clipping {
+			DrawPolyline(v.contour(c), indexBrush(img, sweepClipping))
+		}
+		if !math.IsInf(s.Event.X, 0) {
+			x := int(v.point(s.Event).X)
+			drawline(x, 0, x, size-1, indexBrush(img, sweepLine))
+		}
+
+		output = append(output, s.Output...)
+		for _, e := range output {
+			v.edge(e, indexBrush(img, sweepOutput))
+		}
+		for _, e := range s.Active {
+			v.edge(e, indexBrush(img, uint8(sweepEdgeNormal+int(e.Kind))))
+		}
+		for _, p := range s.Divided {
+			p = v.point(p)
+			x, y := int(p.X), int(p.Y)
+			drawline(x-2, y-2, x+2, y+2, indexBrush(img, sweepDivided))
+			drawline(x-2, y+2, x+2, y-2, indexBrush(img, sweepDivided))
+		}
+
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, 25)
+	}
+	if len(anim.Delay) > 0 {
+		anim.Delay[len(anim.Delay)-1] = 300
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+func indexBrush(img *image.Paletted, index uint8) Putpixel {
+	return func(x, y int) {
+		if (image.Point{x, y}).In(img.Rect) {
+			img.SetColorIndex(x, y, index)
+		}
+	}
+}
+
+// viewport maps polygon coordinates onto a square image, with a small margin
+type viewport struct {
+	min   polyclip.Point
+	scale float64
+	shift float64
+}
+
+func newViewport(bb polyclip.Rectangle, size int) viewport {
+	margin := float64(size) / 20
+	span := math.Max(bb.Max.X-bb.Min.X, bb.Max.Y-bb.Min.Y)
+	scale := 1.0
+	switch {
+	case math.IsInf(span, 0): // no points at all
+		bb.Min = polyclip.Point{}
+	case span > 0:
+		scale = (float64(size) - 1 - 2*margin) / span
+	}
+	return viewport{min: bb.Min, scale: scale, shift: margin}
+}
+
+func (v viewport) point(p polyclip.Point) polyclip.Point {
+	return polyclip.Point{
+		X: (p.X-v.min.X)*v.scale + v.shift,
+		Y: (p.Y-v.min.Y)*v.scale + v.shift,
+	}
+}
+
+func (v viewport) contour(c polyclip.Contour) []polyclip.Point {
+	pts := make([]polyclip.Point, len(c))
+	for i := range c {
+		pts[i] = v.point(c[i])
+	}
+	return pts
+}
+
+func (v viewport) edge(e polyclip.TraceEdge, brush Putpixel) {
+	p0, p1 := v.point(e.Start), v.point(e.End)
+	drawline(int(p0.X), int(p0.Y), int(p1.X), int(p1.Y), brush)
+}
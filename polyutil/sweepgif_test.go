@@ -0,0 +1,55 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bytes"
+	"image/gif"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestEncodeSweepGIF(t *T) {
+	subject := polyclip.Polygon{{{X: 1, Y: 1}, {X: 1, Y: 3}, {X: 3, Y: 3}, {X: 3, Y: 1}}}
+	clipping := polyclip.Polygon{{{X: 2, Y: 2}, {X: 2, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 2}}}
+	far := polyclip.Polygon{{{X: 10, Y: 10}, {X: 10, Y: 11}, {X: 11, Y: 11}}}
+
+	cases := []struct {
+		subject, clipping polyclip.Polygon
+		frames            int
+	}{
+		{subject, clipping, 24},
+		{subject, far, 1},
+		{polyclip.Polygon{}, polyclip.Polygon{}, 1},
+	}
+	for i, c := range cases {
+		buf := &bytes.Buffer{}
+		err := EncodeSweepGIF(buf, c.subject, polyclip.UNION, c.clipping, 100)
+		verify(t, err == nil, "Case %d: expected no error encoding, got: %v", i, err)
+		anim, err := gif.DecodeAll(buf)
+		verify(t, err == nil, "Case %d: expected no error decoding, got: %v", i, err)
+		if err != nil {
+			continue
+		}
+		verify(t, len(anim.Image) == c.frames, "Case %d: expected %d frames, got %d", i, c.frames, len(anim.Image))
+		verify(t, anim.Config.Width == 100 && anim.Config.Height == 100, "Case %d: expected 100x100 image, got %dx%d", i, anim.Config.Width, anim.Config.Height)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// Geometry type codes and EWKB flags used by the Well-Known Binary format
+const (
+	wkbPolygon      = 3
+	wkbMultiPolygon = 6
+
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// wkbReader reads the values of a WKB geometry, remembering the first error
+type wkbReader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+	err   error
+}
+
+func (r *wkbReader) read(n int) []byte {
+	if r.err != nil {
+		return r.buf[:n]
+	}
+	_, r.err = io.ReadFull(r.r, r.buf[:n])
+	if r.err == io.EOF {
+		r.err = io.ErrUnexpectedEOF
+	}
+	return r.buf[:n]
+}
+
+func (r *wkbReader) byteOrder() {
+	r.order = binary.LittleEndian
+	switch b := r.read(1)[0]; {
+	case r.err != nil:
+	case b == 0:
+		r.order = binary.BigEndian
+	case b == 1:
+		r.order = binary.LittleEndian
+	default:
+		r.err = fmt.Errorf("polyutil: WKB: invalid byte order %d", b)
+	}
+}
+
+func (r *wkbReader) uint32() uint32   { return r.order.Uint32(r.read(4)) }
+func (r *wkbReader) float64() float64 { return math.Float64frombits(r.order.Uint64(r.read(8))) }
+
+// header reads the byte order and geometry type of a WKB geometry, returning
+// the base type, the number of coordinates per point and the SRID (or 0).
+func (r *wkbReader) header() (kind uint32, dims int, srid uint32) {
+	r.byteOrder()
+	t := r.uint32()
+	if r.err != nil {
+		return 0, 0, 0
+	}
+	dims = 2
+	if t&ewkbZ != 0 {
+		dims++
+	}
+	if t&ewkbM != 0 {
+		dims++
+	}
+	if t&ewkbSRID != 0 {
+		srid = r.uint32()
+	}
+	t &^= ewkbZ | ewkbM | ewkbSRID
+	// ISO WKB encodes Z, M and ZM variants as 1000+, 2000+ and 3000+
+	switch t / 1000 {
+	case 1, 2:
+		dims++
+	case 3:
+		dims += 2
+	}
+	return t % 1000, dims, srid
+}
+
+const maxWKBCount = 1 << 24
+
+func (r *wkbReader) count(what string) int {
+	n := r.uint32()
+	if r.err == nil && n > maxWKBCount {
+		r.err = fmt.Errorf("polyutil: WKB: too many %s: %d", what, n)
+	}
+	return int(n)
+}
+
+func (r *wkbReader) polygon(p *polyclip.Polygon, dims int) {
+	nrings := r.count("rings")
+	for i := 0; i < nrings && r.err == nil; i++ {
+		npoints := r.count("points")
+		c := polyclip.Contour{}
+		for j := 0; j < npoints && r.err == nil; j++ {
+			pt := polyclip.Point{X: r.float64(), Y: r.float64()}
+			for k := 2; k < dims; k++ {
+				r.float64()
+			}
+			c.Add(pt)
+		}
+		if len(c) > 1 && c[0].Equals(c[len(c)-1]) {
+			c = c[:len(c)-1]
+		}
+		if r.err == nil && len(c) < 3 {
+			r.err = fmt.Errorf("polyutil: WKB: ring with %d distinct points", len(c))
+		}
+		if r.err == nil {
+			p.Add(c)
+		}
+	}
+}
+
+// DecodeWKB reads a single Polygon or MultiPolygon geometry in the Well-Known
+// Binary format, in either byte order. Extended WKB (as produced by PostGIS)
+// is accepted too, in which case its SRID is returned, otherwise the returned
+// SRID is 0. Z and M coordinates are accepted and ignored. As in ParseWKT,
+// the rings of all the polygons become contours of the returned polygon. For
+// hex-encoded WKB, wrap r with hex.NewDecoder.
+func DecodeWKB(r io.Reader) (polyclip.Polygon, uint32, error) {
+	wr := &wkbReader{r: r}
+	kind, dims, srid := wr.header()
+	if wr.err != nil {
+		return nil, 0, wr.err
+	}
+	p := polyclip.Polygon{}
+	switch kind {
+	case wkbPolygon:
+		wr.polygon(&p, dims)
+	case wkbMultiPolygon:
+		n := wr.count("polygons")
+		for i := 0; i < n && wr.err == nil; i++ {
+			k, d, _ := wr.header()
+			if wr.err == nil && k != wkbPolygon {
+				wr.err = fmt.Errorf("polyutil: WKB: unexpected geometry type %d in MultiPolygon", k)
+			}
+			wr.polygon(&p, d)
+		}
+	default:
+		return nil, 0, fmt.Errorf("polyutil: WKB: unsupported geometry type %d", kind)
+	}
+	if wr.err != nil {
+		return nil, 0, wr.err
+	}
+	return p, srid, nil
+}
+
+// EncodeWKB writes p in the Well-Known Binary format, using the given byte
+// order (which must be binary.LittleEndian or binary.BigEndian). If srid is
+// not 0, Extended WKB with the SRID embedded is written instead. Contours are
+// grouped into polygons and oriented as in FormatWKT; an empty p is written
+// as a Polygon with no rings.
+func EncodeWKB(w io.Writer, p polyclip.Polygon, order binary.ByteOrder, srid uint32) error {
+	var flag byte
+	switch order {
+	case binary.BigEndian:
+		flag = 0
+	case binary.LittleEndian:
+		flag = 1
+	default:
+		return errors.New("polyutil: WKB: unsupported byte order")
+	}
+	bw := bufio.NewWriter(w)
+	var buf [8]byte
+	header := func(kind uint32, srid uint32) {
+		bw.WriteByte(flag)
+		if srid != 0 {
+			kind |= ewkbSRID
+		}
+		order.PutUint32(buf[:4], kind)
+		bw.Write(buf[:4])
+		if srid != 0 {
+			order.PutUint32(buf[:4], srid)
+			bw.Write(buf[:4])
+		}
+	}
+	putUint32 := func(v int) {
+		order.PutUint32(buf[:4], uint32(v))
+		bw.Write(buf[:4])
+	}
+	ring := func(c polyclip.Contour, counterClockwise bool) {
+		c = oriented(c, counterClockwise)
+		putUint32(len(c) + 1)
+		for i := 0; i <= len(c); i++ {
+			pt := c[i%len(c)] // the first point again closes the ring
+			order.PutUint64(buf[:], math.Float64bits(pt.X))
+			bw.Write(buf[:])
+			order.PutUint64(buf[:], math.Float64bits(pt.Y))
+			bw.Write(buf[:])
+		}
+	}
+	polygon := func(g ringGroup) {
+		putUint32(1 + len(g.holes))
+		ring(g.outer, true)
+		for _, h := range g.holes {
+			ring(h, false)
+		}
+	}
+
+	groups := groupRings(p)
+	switch len(groups) {
+	case 0:
+		header(wkbPolygon, srid)
+		putUint32(0)
+	case 1:
+		header(wkbPolygon, srid)
+		polygon(groups[0])
+	default:
+		header(wkbMultiPolygon, srid)
+		putUint32(len(groups))
+		for _, g := range groups {
+			header(wkbPolygon, 0)
+			polygon(g)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestDecodeWKB(t *T) {
+	cases := []struct {
+		hex, expected string
+		srid          uint32
+	}{
+		// POLYGON ((0 0, 1 0, 1 1, 0 0)), little endian
+		{"01030000000100000004000000" +
+			"00000000000000000000000000000000" +
+			"000000000000f03f0000000000000000" +
+			"000000000000f03f000000000000f03f" +
+			"00000000000000000000000000000000",
+			"[[{0 0} {1 0} {1 1}]]", 0},
+		// the same in big endian, as EWKB with SRID 4326
+		{"0020000003000010e60000000100000004" +
+			"00000000000000000000000000000000" +
+			"3ff00000000000000000000000000000" +
+			"3ff00000000000003ff0000000000000" +
+			"00000000000000000000000000000000",
+			"[[{0 0} {1 0} {1 1}]]", 4326},
+		// ISO POLYGON Z, little endian
+		{"01eb0300000100000003000000" +
+			"000000000000000000000000000000000000000000002440" +
+			"000000000000f03f00000000000000000000000000002440" +
+			"000000000000f03f000000000000f03f0000000000002440",
+			"[[{0 0} {1 0} {1 1}]]", 0},
+		// POLYGON EMPTY
+		{"010300000000000000", "[]", 0},
+	}
+	for i, c := range cases {
+		p, srid, err := DecodeWKB(hex.NewDecoder(strings.NewReader(c.hex)))
+		verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+		verify(t, fmt.Sprint(p) == c.expected, "Case %d: expected %s, got: %v", i, c.expected, p)
+		verify(t, srid == c.srid, "Case %d: expected SRID %d, got: %d", i, c.srid, srid)
+	}
+
+	errors := []string{
+		"",
+		"02030000000000000000",
+		"010100000000000000000000000000000000000000",
+		"0103000000010000000400000000000000",
+		"01030000000100000002000000" + strings.Repeat("00", 32),
+	}
+	for i, e := range errors {
+		_, _, err := DecodeWKB(hex.NewDecoder(strings.NewReader(e)))
+		verify(t, err != nil, "Error case %d: expected error, got none", i)
+	}
+}
+
+func TestEncodeWKB(t *T) {
+	outer := polyclip.Contour{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	hole := polyclip.Contour{{X: 2, Y: 2}, {X: 2, Y: 8}, {X: 8, Y: 8}, {X: 8, Y: 2}}
+	island := polyclip.Contour{{X: 4, Y: 4}, {X: 6, Y: 4}, {X: 6, Y: 6}}
+	triangle := polyclip.Polygon{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}}
+
+	buf := &bytes.Buffer{}
+	err := EncodeWKB(buf, triangle, binary.LittleEndian, 0)
+	verify(t, err == nil, "Expected no error, got: %v", err)
+	expected := "01030000000100000004000000" +
+		"00000000000000000000000000000000" +
+		"000000000000f03f0000000000000000" +
+		"000000000000f03f000000000000f03f" +
+		"00000000000000000000000000000000"
+	verify(t, hex.EncodeToString(buf.Bytes()) == expected, "Expected %s, got: %x", expected, buf.Bytes())
+
+	buf.Reset()
+	err = EncodeWKB(buf, polyclip.Polygon{}, binary.BigEndian, 0)
+	verify(t, err == nil && hex.EncodeToString(buf.Bytes()) == "000000000300000000", "Expected empty polygon, got: %x %v", buf.Bytes(), err)
+
+	cases := []polyclip.Polygon{
+		triangle,
+		{outer, hole},
+		{outer, hole, island},
+	}
+	for i, p := range cases {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			for _, srid := range []uint32{0, 3857} {
+				buf.Reset()
+				err := EncodeWKB(buf, p, order, srid)
+				verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+				q, s, err := DecodeWKB(buf)
+				verify(t, err == nil && s == srid, "Case %d %v %d: expected SRID, got: %d %v", i, order, srid, s, err)
+				verify(t, fmt.Sprint(groupRings(q)) == fmt.Sprint(groupRings(p)), "Case %d %v %d: expected %v, got: %v", i, order, srid, p, q)
+			}
+		}
+	}
+}
+
+func TestEncodeWKBKeepsInput(t *T) {
+	// a counter-clockwise contour with spare capacity, written as it is
+	c := make(polyclip.Contour, 3, 4)
+	copy(c, polyclip.Contour{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 2}})
+	err := EncodeWKB(&bytes.Buffer{}, polyclip.Polygon{c}, binary.LittleEndian, 0)
+	verify(t, err == nil && c[:4][3] == polyclip.Point{}, "Expected the backing array of the contour untouched, got: %v %v", c[:4], err)
+}
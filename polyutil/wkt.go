@@ -0,0 +1,274 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// wktScanner splits WKT text into tokens: words, numbers and punctuation
+type wktScanner struct {
+	r   io.ByteScanner
+	tok string
+	err error
+}
+
+func isWKTSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// next reads the next token into s.tok; it's empty at the end of input
+func (s *wktScanner) next() {
+	s.tok = ""
+	if s.err != nil {
+		return
+	}
+	c, err := s.r.ReadByte()
+	for err == nil && isWKTSpace(c) {
+		c, err = s.r.ReadByte()
+	}
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		return
+	}
+	if c == '(' || c == ')' || c == ',' || c == ';' || c == '=' {
+		s.tok = string(c)
+		return
+	}
+	tok := []byte{c}
+	for {
+		c, err = s.r.ReadByte()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			break
+		}
+		if isWKTSpace(c) || c == '(' || c == ')' || c == ',' || c == ';' || c == '=' {
+			s.r.UnreadByte()
+			break
+		}
+		tok = append(tok, c)
+	}
+	s.tok = string(tok)
+}
+
+func (s *wktScanner) expect(tok string) error {
+	if err := s.match(tok); err != nil {
+		return err
+	}
+	s.next()
+	return nil
+}
+
+// match checks the current token without advancing past it
+func (s *wktScanner) match(tok string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if !strings.EqualFold(s.tok, tok) {
+		if s.tok == "" {
+			return fmt.Errorf("polyutil: WKT: expected %q, got end of input", tok)
+		}
+		return fmt.Errorf("polyutil: WKT: expected %q, got %q", tok, s.tok)
+	}
+	return nil
+}
+
+func (s *wktScanner) number() (float64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	f, err := strconv.ParseFloat(s.tok, 64)
+	if err != nil {
+		return 0, fmt.Errorf("polyutil: WKT: expected number, got %q", s.tok)
+	}
+	s.next()
+	return f, nil
+}
+
+// ParseWKT reads a single POLYGON or MULTIPOLYGON geometry in the Well-Known
+// Text format, optionally preceded by an EWKT "SRID=...;" prefix (which is
+// ignored). The rings of all the polygons become contours of the returned
+// polygon; the repeated closing points of the rings are dropped. Z and M
+// coordinates are accepted and ignored. Empty geometries give an empty
+// polygon. If r is an io.ByteScanner (e.g. a *bufio.Reader), ParseWKT reads
+// no further than the end of the geometry, so it can be called repeatedly to
+// read subsequent geometries from a stream.
+func ParseWKT(r io.Reader) (polyclip.Polygon, error) {
+	bs, ok := r.(io.ByteScanner)
+	if !ok {
+		bs = bufio.NewReader(r)
+	}
+	s := &wktScanner{r: bs}
+	s.next()
+	if strings.HasPrefix(strings.ToUpper(s.tok), "SRID") {
+		s.next()
+		err := s.expect("=")
+		if err == nil {
+			_, err = s.number()
+		}
+		if err == nil {
+			err = s.expect(";")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	kind := strings.ToUpper(s.tok)
+	if kind != "POLYGON" && kind != "MULTIPOLYGON" {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, fmt.Errorf("polyutil: WKT: unsupported geometry type %q", s.tok)
+	}
+	s.next()
+	dims := 2
+	switch strings.ToUpper(s.tok) {
+	case "Z", "M":
+		dims = 3
+		s.next()
+	case "ZM":
+		dims = 4
+		s.next()
+	}
+	p := polyclip.Polygon{}
+	if strings.EqualFold(s.tok, "EMPTY") {
+		return p, s.err
+	}
+
+	ring := func() error {
+		err := s.expect("(")
+		c := polyclip.Contour{}
+		for err == nil {
+			coords := make([]float64, dims)
+			for i := range coords {
+				coords[i], err = s.number()
+				if err != nil {
+					return err
+				}
+			}
+			c.Add(polyclip.Point{X: coords[0], Y: coords[1]})
+			if s.tok != "," {
+				break
+			}
+			s.next()
+		}
+		if err == nil {
+			err = s.expect(")")
+		}
+		if err != nil {
+			return err
+		}
+		if len(c) > 1 && c[0].Equals(c[len(c)-1]) {
+			c = c[:len(c)-1]
+		}
+		if len(c) < 3 {
+			return fmt.Errorf("polyutil: WKT: ring with %d distinct points", len(c))
+		}
+		p.Add(c)
+		return nil
+	}
+	// list parses a parenthesized, comma-separated list of elements; the
+	// closing parenthesis of the outermost list is not consumed, so that
+	// nothing past the end of the geometry is read
+	list := func(element func() error, outermost bool) error {
+		err := s.expect("(")
+		for err == nil {
+			if strings.EqualFold(s.tok, "EMPTY") {
+				s.next()
+			} else if err = element(); err != nil {
+				return err
+			}
+			if s.tok != "," {
+				break
+			}
+			s.next()
+		}
+		if err != nil {
+			return err
+		}
+		if outermost {
+			return s.match(")")
+		}
+		return s.expect(")")
+	}
+
+	var err error
+	if kind == "POLYGON" {
+		err = list(ring, true)
+	} else {
+		err = list(func() error { return list(ring, false) }, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// FormatWKT writes p in the Well-Known Text format. The contours of p are
+// classified as outer boundaries or holes based on their nesting, and written
+// as a POLYGON if there's a single outer boundary, or a MULTIPOLYGON
+// otherwise. Outer rings are written counter-clockwise and holes clockwise, and
+// all rings are closed by repeating their first point. An empty polygon is
+// written as "POLYGON EMPTY".
+func FormatWKT(w io.Writer, p polyclip.Polygon) error {
+	groups := groupRings(p)
+	ring := func(c polyclip.Contour, counterClockwise bool) string {
+		c = oriented(c, counterClockwise)
+		pts := make([]string, 0, len(c)+1)
+		for i := 0; i <= len(c); i++ {
+			pt := c[i%len(c)] // the first point again closes the ring
+			pts = append(pts, svgNum(pt.X)+" "+svgNum(pt.Y))
+		}
+		return "(" + strings.Join(pts, ", ") + ")"
+	}
+	polygon := func(g ringGroup) string {
+		rings := []string{ring(g.outer, true)}
+		for _, h := range g.holes {
+			rings = append(rings, ring(h, false))
+		}
+		return "(" + strings.Join(rings, ", ") + ")"
+	}
+
+	var err error
+	switch len(groups) {
+	case 0:
+		_, err = io.WriteString(w, "POLYGON EMPTY")
+	case 1:
+		_, err = io.WriteString(w, "POLYGON "+polygon(groups[0]))
+	default:
+		polys := make([]string, len(groups))
+		for i, g := range groups {
+			polys[i] = polygon(g)
+		}
+		_, err = io.WriteString(w, "MULTIPOLYGON ("+strings.Join(polys, ", ")+")")
+	}
+	return err
+}
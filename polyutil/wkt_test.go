@@ -0,0 +1,111 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestParseWKT(t *T) {
+	cases := []struct {
+		input, expected string
+	}{
+		{"POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 8, 8 8, 8 2, 2 2))",
+			"[[{0 0} {10 0} {10 10} {0 10}] [{2 2} {2 8} {8 8} {8 2}]]"},
+		{"multipolygon(((0 0,1 0,1 1,0 0)),((5 5,6 5,6 6,5 5)))",
+			"[[{0 0} {1 0} {1 1}] [{5 5} {6 5} {6 6}]]"},
+		{"SRID=4326;POLYGON Z ((0 0 1, 1 0 1, 1 1 1, 0 0 1))", "[[{0 0} {1 0} {1 1}]]"},
+		{"POLYGON ZM ((0 0 1 2, 1 0 1 2, 1 1 1 2))", "[[{0 0} {1 0} {1 1}]]"},
+		{"MULTIPOLYGON (EMPTY, ((0 0, 1e1 0, 10 -1.5, 0 0)))", "[[{0 0} {10 0} {10 -1.5}]]"},
+		{"POLYGON EMPTY", "[]"},
+		{"MULTIPOLYGON EMPTY", "[]"},
+	}
+	for i, c := range cases {
+		p, err := ParseWKT(strings.NewReader(c.input))
+		verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+		verify(t, fmt.Sprint(p) == c.expected, "Case %d: expected %s, got: %v", i, c.expected, p)
+	}
+
+	errors := []string{
+		"",
+		"POINT (0 0)",
+		"POLYGON ((0 0, 1 0, 0 0))",
+		"POLYGON ((0 0, 1 0, 1 1, 0 0)",
+		"POLYGON ((0 0, 1, 1 1, 0 0))",
+		"POLYGON ((0 0, 1 0, 1 x, 0 0))",
+		"SRID=x;POLYGON EMPTY",
+	}
+	for i, e := range errors {
+		_, err := ParseWKT(strings.NewReader(e))
+		verify(t, err != nil, "Error case %d: expected error, got none", i)
+	}
+
+	// consecutive geometries in a stream
+	r := bufio.NewReader(strings.NewReader("POLYGON ((0 0, 1 0, 1 1))\nPOLYGON EMPTY\nPOLYGON ((5 5, 6 5, 6 6))"))
+	var polys []polyclip.Polygon
+	for i := 0; i < 3; i++ {
+		p, err := ParseWKT(r)
+		verify(t, err == nil, "Stream %d: expected no error, got: %v", i, err)
+		polys = append(polys, p)
+	}
+	verify(t, fmt.Sprint(polys) == "[[[{0 0} {1 0} {1 1}]] [] [[{5 5} {6 5} {6 6}]]]", "Got: %v", polys)
+}
+
+func TestFormatWKT(t *T) {
+	outer := polyclip.Contour{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	hole := polyclip.Contour{{X: 2, Y: 2}, {X: 2, Y: 8}, {X: 8, Y: 8}, {X: 8, Y: 2}}
+	island := polyclip.Contour{{X: 4, Y: 4}, {X: 6, Y: 4}, {X: 6, Y: 6}}
+	cases := []struct {
+		p        polyclip.Polygon
+		expected string
+	}{
+		{polyclip.Polygon{}, "POLYGON EMPTY"},
+		{polyclip.Polygon{outer, hole},
+			"POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 8, 8 8, 8 2, 2 2))"},
+		{polyclip.Polygon{outer, hole, island},
+			"MULTIPOLYGON (((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 8, 8 8, 8 2, 2 2)), ((4 4, 6 4, 6 6, 4 4)))"},
+		{polyclip.Polygon{{{X: 0.5, Y: -1.25}, {X: 1, Y: 0}, {X: 0, Y: 1}}}, "POLYGON ((0.5 -1.25, 1 0, 0 1, 0.5 -1.25))"},
+		// clockwise outer ring gets reversed
+		{polyclip.Polygon{{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 0}}}, "POLYGON ((1 0, 0 1, 0 0, 1 0))"},
+	}
+	for i, c := range cases {
+		buf := &bytes.Buffer{}
+		err := FormatWKT(buf, c.p)
+		verify(t, err == nil, "Case %d: expected no error, got: %v", i, err)
+		verify(t, buf.String() == c.expected, "Case %d: expected %s, got: %s", i, c.expected, buf)
+
+		p, err := ParseWKT(buf)
+		verify(t, err == nil && len(p) == len(c.p), "Case %d: round trip failed: %v %v", i, p, err)
+	}
+}
+
+func TestFormatWKTKeepsInput(t *T) {
+	// a counter-clockwise contour with spare capacity, written as it is
+	c := make(polyclip.Contour, 3, 4)
+	copy(c, polyclip.Contour{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 2}})
+	err := FormatWKT(&bytes.Buffer{}, polyclip.Polygon{c})
+	verify(t, err == nil && c[:4][3] == polyclip.Point{}, "Expected the backing array of the contour untouched, got: %v %v", c[:4], err)
+}
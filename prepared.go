@@ -0,0 +1,469 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"math"
+	"sort"
+)
+
+// PreparedPolygon is a polygon with precomputed data speeding up repeated
+// operations on it: its bounding box, its edges sorted from left to right and
+// indexed by their ranges of X coordinates, the order in which the sweep
+// processes their endpoints, and the sorted coordinates of its vertices. A
+// PreparedPolygon is not modified after creation, so it can be used from many
+// goroutines at once.
+type PreparedPolygon struct {
+	polygon Polygon
+	bbox    Rectangle
+	edges   edgeIndex
+	// endpoints of the edges, in the order of processing by the sweep: 2*i
+	// stands for the left endpoint of edges.edges[i], and 2*i+1 for the
+	// right one
+	events []int
+	xs, ys []float64 // sorted X and Y coordinates of all the vertices
+}
+
+// Prepare analyzes p for repeated operations. The polygon must not be
+// modified afterwards.
+func Prepare(p Polygon) *PreparedPolygon {
+	pp := &PreparedPolygon{polygon: p}
+	if p.NumVertices() == 0 {
+		return pp
+	}
+	pp.bbox = p.BoundingBox()
+	edges := []segment{}
+	for _, c := range p {
+		for i := range c {
+			s := c.segment(i)
+			if s.start.Equals(s.end) {
+				continue
+			}
+			// orient the edge from its left to its right endpoint, as
+			// in addProcessedSegment
+			if s.start.X > s.end.X || (s.start.X == s.end.X && s.start.Y > s.end.Y) {
+				s.start, s.end = s.end, s.start
+			}
+			edges = append(edges, s)
+			pp.xs = append(pp.xs, s.start.X, s.end.X)
+			pp.ys = append(pp.ys, s.start.Y, s.end.Y)
+		}
+	}
+	sort.Float64s(pp.xs)
+	sort.Float64s(pp.ys)
+	pp.edges = newEdgeIndex(edges)
+
+	// sort the endpoints once, like the event queue does
+	endpoints := make([]*endpoint, 2*len(edges))
+	pp.events = make([]int, len(endpoints))
+	for i, s := range pp.edges.edges {
+		endpoints[2*i], endpoints[2*i+1] = newEndpoints(s, _CLIPPING, EdgeSource{Clipping: true})
+		pp.events[2*i], pp.events[2*i+1] = 2*i, 2*i+1
+	}
+	sort.Slice(pp.events, func(i, j int) bool {
+		return endpointLess(endpoints[pp.events[j]], endpoints[pp.events[i]])
+	})
+	return pp
+}
+
+// Polygon returns the prepared polygon.
+func (pp *PreparedPolygon) Polygon() Polygon {
+	return pp.polygon
+}
+
+// BoundingBox returns the bounding box of the prepared polygon.
+func (pp *PreparedPolygon) BoundingBox() Rectangle {
+	return pp.bbox
+}
+
+// Construct computes subject <operation> pp, just like
+// subject.Construct(operation, pp.Polygon()), but the endpoints of the edges
+// of pp aren't sorted again: only the endpoints of subject are, and the two
+// are merged.
+//
+// For intersections and differences, only the edges of pp near subject take
+// part in the computation. Edges on the left of subject are cut at a vertical
+// line between subject and the nearest vertex of pp, edges above it at a
+// horizontal line between it and the nearest vertex of pp above, and edges on
+// the right of it are dropped. Edges below subject are replaced with segments
+// of a horizontal line between it and the nearest vertex of pp below, where
+// the line lies inside pp. This keeps every vertical cross-section of pp over
+// subject the same, so the result can only differ from the one of
+// Polygon.Construct by rounding errors. Unions and XORs use all the edges.
+func (pp *PreparedPolygon) Construct(operation Op, subject Polygon) Polygon {
+	if subject.NumVertices() == 0 || len(pp.xs) == 0 {
+		return subject.Construct(operation, pp.polygon)
+	}
+	inf := math.Inf(1)
+	w := window{left: -inf, right: inf, bottom: -inf, top: inf}
+	if operation == INTERSECTION || operation == DIFFERENCE {
+		sb := subject.BoundingBox()
+		if !sb.Overlaps(pp.bbox) {
+			return subject.Construct(operation, pp.polygon)
+		}
+		w = pp.windowAround(sb)
+	}
+	c := clipper{
+		subject:        subject,
+		clipping:       pp.polygon,
+		clippingEvents: pp.sweepEvents(w),
+	}
+	return c.compute(operation)
+}
+
+// IntersectionArea returns the area of the intersection of subject and pp,
+// with holes subtracted, like polyutil.Area of
+// pp.Construct(INTERSECTION, subject). The contours of the intersection
+// aren't built: the area is summed from its edges during the sweep, only
+// keeping the ends of the chains they're connected into.
+func (pp *PreparedPolygon) IntersectionArea(subject Polygon) float64 {
+	if subject.NumVertices() == 0 || len(pp.xs) == 0 {
+		return 0
+	}
+	sb := subject.BoundingBox()
+	if !sb.Overlaps(pp.bbox) {
+		return 0
+	}
+	c := clipper{
+		subject:        subject,
+		clipping:       pp.polygon,
+		clippingEvents: pp.sweepEvents(pp.windowAround(sb)),
+		areaOnly:       true,
+	}
+	c.compute(INTERSECTION)
+	return c.areas.area
+}
+
+// window is the part of the plane in which the edges of a PreparedPolygon
+// take part in an operation. Its left, bottom and top sides don't pass
+// through any vertex.
+type window struct {
+	left, right, bottom, top float64
+}
+
+// windowAround returns the window for intersecting or subtracting pp from a
+// subject with bounding box sb.
+func (pp *PreparedPolygon) windowAround(sb Rectangle) window {
+	return window{
+		left:   before(pp.xs, sb.Min.X),
+		right:  sb.Max.X,
+		bottom: before(pp.ys, sb.Min.Y),
+		top:    after(pp.ys, sb.Max.Y),
+	}
+}
+
+// before returns a number between v and the greatest of sorted numbers less
+// than v, or -Inf if there's no such number.
+func before(sorted []float64, v float64) float64 {
+	i := sort.SearchFloat64s(sorted, v)
+	if i > 0 {
+		if m := (sorted[i-1] + v) / 2; sorted[i-1] < m && m < v {
+			return m
+		}
+	}
+	return math.Inf(-1)
+}
+
+// after returns a number between v and the least of sorted numbers greater
+// than v, or +Inf if there's no such number.
+func after(sorted []float64, v float64) float64 {
+	i := sort.Search(len(sorted), func(i int) bool { return sorted[i] > v })
+	if i < len(sorted) {
+		if m := (v + sorted[i]) / 2; v < m && m < sorted[i] {
+			return m
+		}
+	}
+	return math.Inf(1)
+}
+
+// atX returns the point of s with X coordinate x
+func atX(s segment, x float64) Point {
+	return Point{x, s.start.Y + (x-s.start.X)*(s.end.Y-s.start.Y)/(s.end.X-s.start.X)}
+}
+
+// atY returns the point of s with Y coordinate y
+func atY(s segment, y float64) Point {
+	return Point{s.start.X + (y-s.start.Y)*(s.end.X-s.start.X)/(s.end.Y-s.start.Y), y}
+}
+
+// sweepEvents returns new endpoints of the edges of pp within w, sorted like
+// in the event queue, as described for Construct. The endpoints of edges
+// lying entirely within w are taken in the order precomputed in pp.events, so
+// only the ones of edges cut by the sides of w need sorting.
+func (pp *PreparedPolygon) sweepEvents(w window) []*endpoint {
+	edges := pp.edges.edges
+	whole := map[int]*endpoint{} // left endpoints of edges within w
+	cut := eventQueue{}          // endpoints of the other edges
+	crossings := []float64{}     // X coordinates where edges cross the bottom of w
+	pp.edges.searchIndices(w.left, w.right, func(i int) {
+		s := edges[i]
+		if s.end.X <= w.left {
+			return
+		}
+		if s.start.X < w.left {
+			s.start = atX(s, w.left)
+		}
+		if math.Min(s.start.Y, s.end.Y) >= w.top || math.Max(s.start.Y, s.end.Y) <= w.bottom {
+			return
+		}
+		switch {
+		case s.start.Y > w.top:
+			s.start = atY(s, w.top)
+		case s.end.Y > w.top:
+			s.end = atY(s, w.top)
+		}
+		switch {
+		case s.start.Y < w.bottom:
+			s.start = atY(s, w.bottom)
+			crossings = append(crossings, s.start.X)
+		case s.end.Y < w.bottom:
+			s.end = atY(s, w.bottom)
+			crossings = append(crossings, s.end.X)
+		}
+		if s != edges[i] {
+			addProcessedSegment(&cut, s, _CLIPPING, EdgeSource{Clipping: true})
+			return
+		}
+		left, right := newEndpoints(s, _CLIPPING, EdgeSource{Clipping: true})
+		whole[i] = left
+		if right.p.X > w.right {
+			// not processed by the sweep, but the queue keeps all the
+			// endpoints
+			cut.enqueue(right)
+		}
+	})
+
+	// replace the edges below w with the parts of its bottom inside pp
+	if !math.IsInf(w.bottom, -1) {
+		sort.Float64s(crossings)
+		inside := !math.IsInf(w.left, -1) && pp.ContainsPoint(Point{w.left, w.bottom})
+		x := w.left
+		for _, next := range crossings {
+			if inside {
+				addProcessedSegment(&cut, segment{Point{x, w.bottom}, Point{next, w.bottom}}, _CLIPPING, EdgeSource{Clipping: true})
+			}
+			inside, x = !inside, next
+		}
+		if inside && x < pp.bbox.Max.X {
+			// the edges crossing the bottom further right weren't searched
+			addProcessedSegment(&cut, segment{Point{x, w.bottom}, Point{pp.bbox.Max.X, w.bottom}}, _CLIPPING, EdgeSource{Clipping: true})
+		}
+	}
+
+	// the endpoints of whole edges up to the right side of w, in the
+	// precomputed order, which is reversed in the queue
+	point := func(k int) Point {
+		if s := edges[pp.events[k]/2]; pp.events[k]%2 == 0 {
+			return s.start
+		} else {
+			return s.end
+		}
+	}
+	lo := sort.Search(len(pp.events), func(k int) bool { return point(k).X > w.left })
+	hi := sort.Search(len(pp.events), func(k int) bool { return point(k).X > w.right })
+	sorted := make([]*endpoint, 0, 2*len(whole))
+	for k := hi - 1; k >= lo; k-- {
+		left := whole[pp.events[k]/2]
+		switch {
+		case left == nil:
+		case pp.events[k]%2 == 0:
+			sorted = append(sorted, left)
+		default:
+			sorted = append(sorted, left.other)
+		}
+	}
+	cut.merge(sorted)
+	return cut.elements
+}
+
+// ContainsPoint reports whether p lies inside the prepared polygon, under the
+// even-odd rule. Points on the boundary may be reported either way.
+func (pp *PreparedPolygon) ContainsPoint(p Point) bool {
+	inside := false
+	pp.edges.search(p.X, p.X, func(s segment) {
+		// count edges crossing the vertical ray going up from p; the
+		// left endpoint belongs to the edge, the right one doesn't
+		if s.start.X <= p.X && p.X < s.end.X && signedArea(s.start, s.end, p) < 0 {
+			inside = !inside
+		}
+	})
+	return inside
+}
+
+// searchRect calls fn for each edge of pp whose bounding box overlaps r
+func (pp *PreparedPolygon) searchRect(r Rectangle, fn func(segment)) {
+	pp.edges.search(r.Min.X, r.Max.X, func(e segment) {
+		if math.Max(e.start.Y, e.end.Y) >= r.Min.Y && math.Min(e.start.Y, e.end.Y) <= r.Max.Y {
+			fn(e)
+		}
+	})
+}
+
+// edgesNear reports whether any edge of pp has a bounding box overlapping r
+func (pp *PreparedPolygon) edgesNear(r Rectangle) bool {
+	found := false
+	pp.searchRect(r, func(segment) { found = true })
+	return found
+}
+
+// crossesEdges reports whether any edge of p touches or crosses an edge of pp
+func (pp *PreparedPolygon) crossesEdges(p Polygon) bool {
+	for _, c := range p {
+		for i := range c {
+			s := c.segment(i)
+			found := false
+			pp.searchRect(Rectangle{s.start, s.start}.union(Rectangle{s.end, s.end}), func(e segment) {
+				if !found {
+					n, _, _ := findIntersection(s, e)
+					found = n > 0
+				}
+			})
+			if found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evenOddContains reports whether pt is inside p under the even-odd rule
+func evenOddContains(p Polygon, pt Point) bool {
+	inside := false
+	for _, c := range p {
+		if c.Contains(pt) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Intersects reports whether the regions of subject and pp have any common
+// points. Touching boundaries count as intersecting.
+func (pp *PreparedPolygon) Intersects(subject Polygon) bool {
+	if subject.NumVertices() == 0 || len(pp.xs) == 0 || !subject.BoundingBox().Overlaps(pp.bbox) {
+		return false
+	}
+	if pp.crossesEdges(subject) {
+		return true
+	}
+	// with no crossing edges, either one of the regions contains a contour
+	// of the other, or they're disjoint
+	for _, c := range subject {
+		if len(c) > 0 && pp.ContainsPoint(c[0]) {
+			return true
+		}
+	}
+	for _, c := range pp.polygon {
+		if len(c) > 0 && evenOddContains(subject, c[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the region of subject lies inside the region of
+// pp. Subjects touching the boundary of pp are not considered contained.
+func (pp *PreparedPolygon) Contains(subject Polygon) bool {
+	if subject.NumVertices() == 0 || len(pp.xs) == 0 {
+		return false
+	}
+	sb := subject.BoundingBox()
+	if sb.Min.X < pp.bbox.Min.X || sb.Max.X > pp.bbox.Max.X || sb.Min.Y < pp.bbox.Min.Y || sb.Max.Y > pp.bbox.Max.Y {
+		return false
+	}
+	if pp.crossesEdges(subject) {
+		return false
+	}
+	for _, c := range subject {
+		if len(c) > 0 && !pp.ContainsPoint(c[0]) {
+			return false
+		}
+	}
+	// a hole of pp may lie inside subject
+	for _, c := range pp.polygon {
+		if len(c) > 0 && evenOddContains(subject, c[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// edgeIndex is an interval tree of edges, keyed by their ranges of X
+// coordinates. It's stored implicitly in an array sorted by the X coordinate
+// of the left endpoints: the root of the tree over a range of the array is
+// its middle element, and maxX holds the maximum X coordinate of right
+// endpoints in each subtree.
+type edgeIndex struct {
+	edges []segment
+	maxX  []float64
+}
+
+func newEdgeIndex(edges []segment) edgeIndex {
+	sort.Slice(edges, func(i, j int) bool { return edges[i].start.X < edges[j].start.X })
+	idx := edgeIndex{edges: edges, maxX: make([]float64, len(edges))}
+	idx.build(0, len(edges))
+	return idx
+}
+
+func (idx *edgeIndex) build(lo, hi int) float64 {
+	mid := (lo + hi) / 2
+	m := idx.edges[mid].end.X
+	if lo < mid {
+		if l := idx.build(lo, mid); l > m {
+			m = l
+		}
+	}
+	if mid+1 < hi {
+		if r := idx.build(mid+1, hi); r > m {
+			m = r
+		}
+	}
+	idx.maxX[mid] = m
+	return m
+}
+
+// search calls fn for each edge whose range of X coordinates overlaps
+// [x0, x1], in the order of their left endpoints.
+func (idx *edgeIndex) search(x0, x1 float64, fn func(segment)) {
+	idx.searchIndices(x0, x1, func(i int) { fn(idx.edges[i]) })
+}
+
+// searchIndices works like search, but passes indices of the edges to fn.
+func (idx *edgeIndex) searchIndices(x0, x1 float64, fn func(int)) {
+	idx.searchRange(0, len(idx.edges), x0, x1, fn)
+}
+
+func (idx *edgeIndex) searchRange(lo, hi int, x0, x1 float64, fn func(int)) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	if idx.maxX[mid] < x0 {
+		return
+	}
+	idx.searchRange(lo, mid, x0, x1, fn)
+	if idx.edges[mid].start.X > x1 {
+		return
+	}
+	if idx.edges[mid].end.X >= x0 {
+		fn(mid)
+	}
+	idx.searchRange(mid+1, hi, x0, x1, fn)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"math"
+	"math/rand"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// comb returns a concave polygon with many teeth and a hole, so that
+// vertical cross-sections of it vary a lot.
+func comb() polyclip.Polygon {
+	c := polyclip.Contour{{X: 0, Y: 0}, {X: 200, Y: 0}}
+	for i := 20; i > 0; i-- {
+		x := float64(i) * 10
+		c.Add(polyclip.Point{X: x, Y: 100 + float64(i%3)*7})
+		c.Add(polyclip.Point{X: x - 5, Y: 30 + float64(i%4)*5})
+	}
+	hole := polyclip.Contour{{X: 60.5, Y: 10.5}, {X: 60.5, Y: 20.5}, {X: 140.5, Y: 20.5}, {X: 140.5, Y: 10.5}}
+	return polyclip.Polygon{c, hole}
+}
+
+func TestPreparedConstruct(t *T) {
+	clip := comb()
+	pp := polyclip.Prepare(clip)
+	subjects := append(smallPolygons(300), polyclip.Polygon{})
+	for _, op := range []polyclip.Op{polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.UNION, polyclip.XOR} {
+		for i, s := range subjects {
+			expected := evenOddArea(s.Construct(op, clip))
+			if a := evenOddArea(pp.Construct(op, s)); math.Abs(a-expected) > 1e-6 {
+				t.Errorf("Op %v, subject %d %v: expected area %v, got: %v", op, i, s, expected, a)
+			}
+		}
+	}
+}
+
+func TestPreparedConstructNested(t *T) {
+	// nested squares, so that many edges lie above and below the subjects
+	clip := polyclip.Polygon{}
+	for i := 0.0; i < 10; i++ {
+		clip.Add(polyclip.Contour{{X: i * 5, Y: i * 5}, {X: 100 - i*5, Y: i * 5}, {X: 100 - i*5, Y: 100 - i*5}, {X: i * 5, Y: 100 - i*5}})
+	}
+	pp := polyclip.Prepare(clip)
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 300; i++ {
+		s := polyclip.Polygon{{}}
+		x, y := r.Float64()*100, r.Float64()*100
+		for j := 0; j < 3+i%3; j++ {
+			s[0].Add(polyclip.Point{X: x + r.Float64()*8, Y: y + r.Float64()*8})
+		}
+		for _, op := range []polyclip.Op{polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.UNION, polyclip.XOR} {
+			expected := evenOddArea(s.Construct(op, clip))
+			if a := evenOddArea(pp.Construct(op, s)); math.Abs(a-expected) > 1e-6 {
+				t.Errorf("Op %v, subject %d %v: expected area %v, got: %v", op, i, s, expected, a)
+			}
+		}
+	}
+}
+
+func TestPreparedPredicates(t *T) {
+	clip := comb()
+	pp := polyclip.Prepare(clip)
+	for i, s := range smallPolygons(300) {
+		area := evenOddArea(s.Construct(polyclip.INTERSECTION, clip))
+		if pp.Intersects(s) != (area > 0) {
+			t.Errorf("Subject %d %v: expected Intersects %v", i, s, area > 0)
+		}
+		outside := evenOddArea(s.Construct(polyclip.DIFFERENCE, clip))
+		if pp.Contains(s) != (area > 0 && outside < 1e-9) {
+			t.Errorf("Subject %d %v: expected Contains %v", i, s, area > 0 && outside < 1e-9)
+		}
+	}
+
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 1000; i++ {
+		p := polyclip.Point{X: r.Float64()*220 - 10, Y: r.Float64()*140 - 10}
+		expected := clip[0].Contains(p) != clip[1].Contains(p)
+		if pp.ContainsPoint(p) != expected {
+			t.Errorf("Point %v: expected ContainsPoint %v", p, expected)
+		}
+	}
+
+	empty := polyclip.Prepare(polyclip.Polygon{})
+	s := smallPolygons(1)[0]
+	if empty.Intersects(s) || empty.Contains(s) || empty.ContainsPoint(s[0][0]) {
+		t.Errorf("Expected empty prepared polygon to contain nothing")
+	}
+	if len(empty.Construct(polyclip.INTERSECTION, s)) != 0 {
+		t.Errorf("Expected empty intersection with empty prepared polygon")
+	}
+}
+
+func BenchmarkPrepared(b *B) {
+	clip := comb()
+	subjects := smallPolygons(200)
+	b.Run("Construct", func(b *B) {
+		for i := 0; i < b.N; i++ {
+			for _, s := range subjects {
+				s.Construct(polyclip.INTERSECTION, clip)
+			}
+		}
+	})
+	b.Run("Prepared", func(b *B) {
+		pp := polyclip.Prepare(clip)
+		for i := 0; i < b.N; i++ {
+			for _, s := range subjects {
+				pp.Construct(polyclip.INTERSECTION, s)
+			}
+		}
+	})
+}
+
+func TestPreparedIntersectionArea(t *T) {
+	nested := polyclip.Polygon{}
+	for i := 0.0; i < 10; i++ {
+		nested.Add(polyclip.Contour{{X: i * 5, Y: i * 5}, {X: 100 - i*5, Y: i * 5}, {X: 100 - i*5, Y: 100 - i*5}, {X: i * 5, Y: 100 - i*5}})
+	}
+	subjects := append(smallPolygons(300), polyclip.Polygon{}, comb(), nested)
+	for _, clip := range []polyclip.Polygon{comb(), nested} {
+		pp := polyclip.Prepare(clip)
+		for i, s := range subjects {
+			expected := evenOddArea(pp.Construct(polyclip.INTERSECTION, s))
+			if a := pp.IntersectionArea(s); math.Abs(a-expected) > 1e-6 {
+				t.Errorf("Subject %d %v: expected area %v, got: %v", i, s, expected, a)
+			}
+		}
+	}
+}
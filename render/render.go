@@ -0,0 +1,201 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package render draws polygons into images, for inspecting inputs and
+// results of Boolean operations.
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/akavel/polyclip-go"
+	"github.com/akavel/polyclip-go/polyutil"
+)
+
+// Style describes how a layer is drawn. Nil colors mean that the respective
+// element is not drawn.
+type Style struct {
+	Fill       color.Color       // color of the interior
+	Rule       polyutil.FillRule // rule used to find the interior
+	Stroke     color.Color       // color of the outlines of contours
+	Marker     color.Color       // color of the vertex markers
+	MarkerSize int               // size of the vertex markers, in pixels; 3 if zero
+}
+
+// Layer is a polygon drawn with specified style.
+type Layer struct {
+	Polygon polyclip.Polygon
+	Style
+}
+
+var defaultColors = []color.NRGBA{
+	{0, 0, 0xff, 0xff},
+	{0xff, 0, 0, 0xff},
+	{0, 0xc0, 0, 0xff},
+	{0xff, 0xa0, 0, 0xff},
+	{0xc0, 0, 0xc0, 0xff},
+}
+
+// DefaultStyle returns a style with a translucent fill and an opaque outline,
+// with colors distinct for subsequent values of i.
+func DefaultStyle(i int) Style {
+	c := defaultColors[i%len(defaultColors)]
+	fill := c
+	fill.A = 0x60
+	return Style{Fill: fill, Stroke: c}
+}
+
+// Options describe the canvas on which the layers are drawn.
+type Options struct {
+	// Width and Height are the size of the image in pixels.
+	Width, Height int
+	// Viewport is the part of the plane shown in the image. If it's empty,
+	// the bounding box of all layers is used. The viewport is scaled
+	// uniformly, so that it fits in the image, and centered.
+	Viewport polyclip.Rectangle
+	// Margin is the number of pixels left free around the viewport.
+	Margin int
+	// FlipY makes the Y axis grow upwards, as usual in math and maps,
+	// instead of downwards, as usual for images.
+	FlipY bool
+	// Background is the color of the canvas; transparent if nil.
+	Background color.Color
+}
+
+// Render draws the layers, in order, on a new image. The polygons are not
+// modified.
+func Render(opts Options, layers ...Layer) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	if opts.Background != nil {
+		draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+	}
+	t := newTransform(opts, layers)
+
+	for _, l := range layers {
+		p := t.polygon(l.Polygon)
+		if l.Fill != nil {
+			mask := image.NewAlpha(img.Bounds())
+			polyutil.FillPolygonAAClipped(p, l.Rule, img.Bounds(), func(x, y int, coverage float64) {
+				mask.SetAlpha(x, y, color.Alpha{uint8(math.Round(coverage * 0xff))})
+			})
+			draw.DrawMask(img, img.Bounds(), image.NewUniform(l.Fill), image.Point{}, mask, image.Point{}, draw.Over)
+		}
+		if l.Stroke != nil {
+			mask := image.NewAlpha(img.Bounds())
+			for _, c := range p {
+				polyutil.DrawPolyline(c, func(x, y int) { mask.SetAlpha(x, y, color.Alpha{0xff}) })
+			}
+			draw.DrawMask(img, img.Bounds(), image.NewUniform(l.Stroke), image.Point{}, mask, image.Point{}, draw.Over)
+		}
+		if l.Marker != nil {
+			size := l.MarkerSize
+			if size == 0 {
+				size = 3
+			}
+			for _, c := range p {
+				for _, pt := range c {
+					r := image.Rect(0, 0, size, size).Add(image.Pt(int(pt.X)-size/2, int(pt.Y)-size/2))
+					draw.Draw(img, r, image.NewUniform(l.Marker), image.Point{}, draw.Over)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// EncodePNG renders the layers and writes the image to w in PNG format.
+func EncodePNG(w io.Writer, opts Options, layers ...Layer) error {
+	return png.Encode(w, Render(opts, layers...))
+}
+
+// transform maps coordinates of polygons to pixels
+type transform struct {
+	scale  float64
+	dx, dy float64
+	flipY  bool
+	height float64
+}
+
+func newTransform(opts Options, layers []Layer) transform {
+	vp := opts.Viewport
+	if vp.Min.Equals(vp.Max) {
+		vp = bounds(layers)
+	}
+	w := float64(opts.Width - 2*opts.Margin)
+	h := float64(opts.Height - 2*opts.Margin)
+	vw, vh := vp.Max.X-vp.Min.X, vp.Max.Y-vp.Min.Y
+
+	t := transform{scale: 1, flipY: opts.FlipY, height: float64(opts.Height)}
+	switch {
+	case vw > 0 && vh > 0:
+		t.scale = math.Min(w/vw, h/vh)
+	case vw > 0:
+		t.scale = w / vw
+	case vh > 0:
+		t.scale = h / vh
+	}
+	// center the viewport in the image
+	t.dx = float64(opts.Margin) + (w-vw*t.scale)/2 - vp.Min.X*t.scale
+	t.dy = float64(opts.Margin) + (h-vh*t.scale)/2 - vp.Min.Y*t.scale
+	return t
+}
+
+func (t transform) point(p polyclip.Point) polyclip.Point {
+	q := polyclip.Point{X: p.X*t.scale + t.dx, Y: p.Y*t.scale + t.dy}
+	if t.flipY {
+		q.Y = t.height - q.Y
+	}
+	return q
+}
+
+func (t transform) polygon(p polyclip.Polygon) polyclip.Polygon {
+	r := make(polyclip.Polygon, len(p))
+	for i, c := range p {
+		r[i] = make(polyclip.Contour, len(c))
+		for j, pt := range c {
+			r[i][j] = t.point(pt)
+		}
+	}
+	return r
+}
+
+func bounds(layers []Layer) polyclip.Rectangle {
+	bb := polyclip.Rectangle{}
+	first := true
+	for _, l := range layers {
+		for _, c := range l.Polygon {
+			if len(c) == 0 {
+				continue
+			}
+			cbb := c.BoundingBox()
+			if first {
+				bb, first = cbb, false
+				continue
+			}
+			bb.Min.X, bb.Min.Y = math.Min(bb.Min.X, cbb.Min.X), math.Min(bb.Min.Y, cbb.Min.Y)
+			bb.Max.X, bb.Max.Y = math.Max(bb.Max.X, cbb.Max.X), math.Max(bb.Max.Y, cbb.Max.Y)
+		}
+	}
+	return bb
+}
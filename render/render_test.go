@@ -0,0 +1,88 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func verify(t *T, cond bool, format string, args ...interface{}) {
+	if !cond {
+		t.Errorf(format, args...)
+	}
+}
+
+func TestRender(t *T) {
+	// unit square in the bottom-left corner of the viewport
+	square := polyclip.Polygon{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}}
+	opts := Options{
+		Width: 100, Height: 100,
+		Viewport: polyclip.Rectangle{Max: polyclip.Point{X: 2, Y: 2}},
+	}
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+
+	img := Render(opts, Layer{square, Style{Fill: red}})
+	verify(t, img.NRGBAAt(25, 25) == red, "Expected filled square at top-left, got: %v", img.NRGBAAt(25, 25))
+	verify(t, img.NRGBAAt(75, 75).A == 0, "Expected transparent bottom-right, got: %v", img.NRGBAAt(75, 75))
+
+	opts.FlipY = true
+	opts.Background = color.White
+	img = Render(opts, Layer{square, Style{Fill: red}})
+	verify(t, img.NRGBAAt(25, 75) == red, "Expected filled square at bottom-left, got: %v", img.NRGBAAt(25, 75))
+	verify(t, img.NRGBAAt(25, 25) == color.NRGBA{0xff, 0xff, 0xff, 0xff}, "Expected white background, got: %v", img.NRGBAAt(25, 25))
+	verify(t, square[0][1].X == 1 && square[0][2].Y == 1, "Expected polygon not modified, got: %v", square)
+
+	// fit to layers, with margin
+	img = Render(Options{Width: 100, Height: 50, Margin: 5}, Layer{square, DefaultStyle(0)})
+	verify(t, img.NRGBAAt(50, 25).A != 0, "Expected fill in the center, got: %v", img.NRGBAAt(50, 25))
+	verify(t, img.NRGBAAt(20, 25).A == 0, "Expected empty left side, got: %v", img.NRGBAAt(20, 25))
+	verify(t, img.NRGBAAt(50, 2).A == 0, "Expected empty margin, got: %v", img.NRGBAAt(50, 2))
+	verify(t, img.NRGBAAt(50, 5) == defaultColors[0], "Expected outline at the top, got: %v", img.NRGBAAt(50, 5))
+
+	// vertex markers
+	img = Render(Options{Width: 100, Height: 100, Margin: 10}, Layer{square, Style{Marker: red, MarkerSize: 5}})
+	verify(t, img.NRGBAAt(11, 11) == red, "Expected vertex marker, got: %v", img.NRGBAAt(11, 11))
+	verify(t, img.NRGBAAt(50, 50).A == 0, "Expected no fill, got: %v", img.NRGBAAt(50, 50))
+
+	buf := &bytes.Buffer{}
+	err := EncodePNG(buf, opts, Layer{square, DefaultStyle(1)})
+	verify(t, err == nil, "Expected no error encoding, got: %v", err)
+	_, err = png.Decode(buf)
+	verify(t, err == nil, "Expected no error decoding, got: %v", err)
+}
+
+func TestRenderZoomed(t *T) {
+	// a viewport much smaller than the polygon only fills the image
+	huge := polyclip.Polygon{{{X: -1e6, Y: -1e6}, {X: 1e6, Y: -1e6}, {X: 1e6, Y: 1e6}, {X: -1e6, Y: 1e6}}}
+	opts := Options{
+		Width: 50, Height: 50,
+		Viewport: polyclip.Rectangle{Max: polyclip.Point{X: 1, Y: 1}},
+	}
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	img := Render(opts, Layer{huge, Style{Fill: red}})
+	for _, pt := range [][2]int{{0, 0}, {25, 25}, {49, 49}} {
+		verify(t, img.NRGBAAt(pt[0], pt[1]) == red, "Expected fill at %v, got: %v", pt, img.NRGBAAt(pt[0], pt[1]))
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrOpenChains is returned by Result.Err when some edges of the result could
+// not be connected into closed contours.
+var ErrOpenChains = errors.New("polyclip: result contains unclosed chains")
+
+// ResultOf is an outcome of a Boolean operation on polygons with coordinates
+// of type T.
+type ResultOf[T Coord] struct {
+	// Polygon is built of all the closed contours of the result.
+	Polygon PolygonOf[T]
+	// OpenChains lists the sequences of result edges which could not be
+	// connected into closed contours. This should never happen, so a
+	// non-empty OpenChains means that the library hit one of its known
+	// bugs, and Polygon is most likely missing some parts.
+	OpenChains []ContourOf[T]
+}
+
+// Result is an outcome of a Boolean operation on polygons with float64
+// coordinates.
+type Result = ResultOf[float64]
+
+// Err returns ErrOpenChains if the result contains open chains, or nil
+// otherwise.
+func (r ResultOf[T]) Err() error {
+	if len(r.OpenChains) > 0 {
+		return ErrOpenChains
+	}
+	return nil
+}
+
+// CloseStrategy describes how Result.Close should treat open chains.
+type CloseStrategy int
+
+const (
+	// DropOpen discards all open chains, like Construct does.
+	DropOpen CloseStrategy = iota
+	// CloseEach closes every open chain on its own, by connecting its last
+	// point with its first one.
+	CloseEach
+	// JoinNearest greedily links the open chains into contours, each time
+	// connecting the end of the contour being built with the nearest free
+	// endpoint of any chain, and closing the contour when its own first point
+	// is the nearest.
+	JoinNearest
+)
+
+// Close returns the result polygon with the open chains turned into contours
+// according to the specified strategy. Chains (or joined contours) having less
+// than 3 points are dropped. Note that this is only a best-effort attempt at
+// recovering from a bug in the library; there's no guarantee that the
+// returned polygon is the correct result of the operation.
+func (r ResultOf[T]) Close(strategy CloseStrategy) PolygonOf[T] {
+	poly := r.Polygon.Clone()
+	switch strategy {
+	case CloseEach:
+		for _, c := range r.OpenChains {
+			if len(c) >= 3 {
+				poly.Add(c.Clone())
+			}
+		}
+	case JoinNearest:
+		for _, c := range joinNearest(r.OpenChains) {
+			if len(c) >= 3 {
+				poly.Add(c)
+			}
+		}
+	}
+	return poly
+}
+
+func joinNearest[T Coord](chains []ContourOf[T]) []ContourOf[T] {
+	free := make([]ContourOf[T], len(chains))
+	copy(free, chains)
+	dist := func(p1, p2 PointOf[T]) float64 {
+		return math.Hypot(span(p2.X, p1.X), span(p2.Y, p1.Y))
+	}
+
+	var result []ContourOf[T]
+	for len(free) > 0 {
+		c := free[0].Clone()
+		free = free[1:]
+		for len(free) > 0 {
+			back := c[len(c)-1]
+			best, bestDist, reverse := -1, math.Inf(1), false
+			if len(c) >= 3 {
+				bestDist = dist(back, c[0])
+			}
+			for i, f := range free {
+				// on ties, prefer joining chains over closing the contour
+				if d := dist(back, f[0]); d <= bestDist {
+					best, bestDist, reverse = i, d, false
+				}
+				if d := dist(back, f[len(f)-1]); d < bestDist {
+					best, bestDist, reverse = i, d, true
+				}
+			}
+			if best == -1 {
+				break // own front is the nearest, close the contour
+			}
+			next := free[best]
+			if reverse {
+				next = reversed(next)
+			}
+			if next[0].Equals(back) {
+				next = next[1:]
+			}
+			c = append(c, next...)
+			free = append(free[:best], free[best+1:]...)
+		}
+		if len(c) > 1 && c[0].Equals(c[len(c)-1]) {
+			c = c[:len(c)-1]
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// ConstructResult works like Construct, but additionally reports the edges of
+// the result which could not be connected into closed contours.
+func (p PolygonOf[T]) ConstructResult(operation Op, clipping PolygonOf[T]) ResultOf[T] {
+	c := clipper{
+		subject:  p.float(),
+		clipping: clipping.float(),
+	}
+	result := c.compute(operation)
+	return ResultOf[T]{Polygon: polygonOf[T](result), OpenChains: polygonOf[T](c.openChains)}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"fmt"
+	. "testing"
+)
+
+func TestConstructResult(t *T) {
+	subject := Polygon{{{1, 1}, {1, 3}, {3, 3}, {3, 1}}}
+	clipping := Polygon{{{2, 2}, {2, 4}, {4, 4}, {4, 2}}}
+	r := subject.ConstructResult(UNION, clipping)
+	verify(t, r.Err() == nil, "Expected no error, got: %v", r.Err())
+	verify(t, len(r.OpenChains) == 0, "Expected no open chains, got: %v", r.OpenChains)
+	verify(t, len(r.Polygon) == 1, "Expected 1 contour, got: %v", r.Polygon)
+
+	// github issue #3, simplified variant
+	subject = Polygon{{{1, 2}, {2, 2}, {2, 1}}}
+	clipping = Polygon{
+		{{2, 1}, {2, 2}, {3, 2}},
+		{{1, 2}, {2, 3}, {2, 2}},
+		{{2, 2}, {2, 3}, {3, 2}}}
+	r = subject.ConstructResult(UNION, clipping)
+	verify(t, r.Err() == ErrOpenChains, "Expected ErrOpenChains, got: %v", r.Err())
+	verify(t, len(r.OpenChains) == 1, "Expected 1 open chain, got: %v", r.OpenChains)
+	verify(t, len(r.Close(DropOpen)) == len(r.Polygon), "Expected open chains dropped, got: %v", r.Close(DropOpen))
+	verify(t, len(r.Close(CloseEach)) == len(r.Polygon)+1, "Expected open chain closed, got: %v", r.Close(CloseEach))
+}
+
+func TestJoinNearest(t *T) {
+	cases := []struct {
+		chains []Contour
+		result string
+	}{
+		{
+			chains: []Contour{{{0, 0}, {1, 0}}, {{0, 1}, {1, 1}}},
+			result: "[[{0 0} {1 0} {1 1} {0 1}]]",
+		},
+		{
+			chains: []Contour{{{0, 0}, {1, 0}}, {{1, 0}, {1, 1}}, {{1, 1}, {0, 0}}},
+			result: "[[{0 0} {1 0} {1 1}]]",
+		},
+		{
+			chains: []Contour{{{0, 0}, {1, 0}, {1, 1}}, {{5, 5}, {6, 5}, {6, 6}}},
+			result: "[[{0 0} {1 0} {1 1}] [{5 5} {6 5} {6 6}]]",
+		},
+	}
+	for i, c := range cases {
+		result := fmt.Sprint(joinNearest(c.chains))
+		verify(t, result == c.result, "Case %d: expected %s, got %s", i, c.result, result)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+// EdgeSource identifies an edge of one of the polygons passed to
+// ConstructSources or Arrangement.
+type EdgeSource struct {
+	Clipping bool // true if the edge comes from the clipping polygon, false if from the subject
+	// Polygon is the index of the polygon among the ones passed to
+	// Arrangement; it's always 0 for ConstructSources, where Clipping tells
+	// the two polygons apart.
+	Polygon int
+	// Contour and Edge are the indices of the contour, and of the edge in
+	// it; edge i of a contour goes from its point i to point i+1 (or to
+	// point 0, for the last edge).
+	Contour, Edge int
+}
+
+// ConstructSources works like Construct, but additionally tells which edge of
+// the input polygons each edge of the result was cut from: sources[i][j] is
+// the source of the edge going from result[i][j] to the next point of the
+// contour. This allows e.g. to tell which parts of the boundary of an
+// intersection come from the subject, and which from the clipping polygon.
+// Where edges of both polygons overlap, the result edge is attributed to only
+// one of them.
+func (p PolygonOf[T]) ConstructSources(operation Op, clipping PolygonOf[T]) (result PolygonOf[T], sources [][]EdgeSource) {
+	c := clipper{
+		subject:     p.float(),
+		clipping:    clipping.float(),
+		withSources: true,
+	}
+	result = polygonOf[T](c.compute(operation))
+	return result, c.sources
+}
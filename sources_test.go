@@ -0,0 +1,92 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// onSegment reports whether p lies on the segment a-b, up to rounding errors
+func onSegment(p, a, b polyclip.Point) bool {
+	d := polyclip.Point{X: b.X - a.X, Y: b.Y - a.Y}
+	l := d.Length()
+	cross := (p.X-a.X)*d.Y - (p.Y-a.Y)*d.X
+	dot := (p.X-a.X)*d.X + (p.Y-a.Y)*d.Y
+	const eps = 1e-9
+	return math.Abs(cross) <= eps*l && dot >= -eps*l && dot <= l*l+eps*l
+}
+
+// randomPolygon returns a polygon of two random, possibly self-intersecting
+// contours
+func randomPolygon(r *rand.Rand) polyclip.Polygon {
+	p := polyclip.Polygon{}
+	for i := 0; i < 2; i++ {
+		c := polyclip.Contour{}
+		for j := 0; j < 3+r.Intn(5); j++ {
+			c.Add(polyclip.Point{X: r.Float64() * 10, Y: r.Float64() * 10})
+		}
+		p.Add(c)
+	}
+	return p
+}
+
+func TestConstructSources(t *T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 50; i++ {
+		subject, clipping := randomPolygon(r), randomPolygon(r)
+		if i%10 == 0 {
+			// a trivial case, without the sweep
+			clipping = square(20, 20, 1)
+		}
+		for _, op := range []polyclip.Op{polyclip.UNION, polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.XOR} {
+			result, sources := subject.ConstructSources(op, clipping)
+			if expected := subject.Construct(op, clipping); !reflect.DeepEqual(result, expected) {
+				t.Errorf("Case %d, op %v: expected result %v, got: %v", i, op, expected, result)
+			}
+			if len(sources) != len(result) {
+				t.Errorf("Case %d, op %v: expected sources of %d contours, got: %d", i, op, len(result), len(sources))
+				continue
+			}
+			for j, c := range result {
+				if len(sources[j]) != len(c) {
+					t.Errorf("Case %d, op %v: contour %d: expected %d sources, got: %d", i, op, j, len(c), len(sources[j]))
+					continue
+				}
+				for k, src := range sources[j] {
+					input := subject
+					if src.Clipping {
+						input = clipping
+					}
+					in := input[src.Contour]
+					a, b := in[src.Edge], in[(src.Edge+1)%len(in)]
+					p, q := c[k], c[(k+1)%len(c)]
+					if !onSegment(p, a, b) || !onSegment(q, a, b) {
+						t.Errorf("Case %d, op %v: edge %v-%v doesn't lie on its source %+v: %v-%v", i, op, p, q, src, a, b)
+					}
+				}
+			}
+		}
+	}
+}
@@ -55,7 +55,7 @@ func safebbox(p polyclip.Polygon) polyclip.Rectangle {
 	return p.BoundingBox()
 }
 
-// Warning: does modify contents of polys
+// Warning: does modify contents of polys; see package render for an alternative which does not.
 func DrawPolygons(mul float64, polys []polyclip.Polygon) *image.NRGBA {
 	img := image.NewNRGBA(image.Rect(0, 0, 0, 0))
 	min := polyclip.Point{0, 0}
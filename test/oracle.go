@@ -0,0 +1,194 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/akavel/polyclip-go"
+	"github.com/akavel/polyclip-go/polyutil"
+)
+
+// Fraction of a pixel at which samples are taken. It's deliberately not 0.5,
+// so that samples are unlikely to fall exactly on edges of polygons with
+// "round" coordinates.
+const sampleOffset = 0.4637
+
+// Mismatch describes the differences between the actual result of a Boolean
+// operation and the one expected by Oracle.
+type Mismatch struct {
+	// Pixels is the number of samples where the result differs from the
+	// expectation, and Area is the corresponding area in polygon units.
+	Pixels int
+	Area   float64
+	// Locations are the positions of the mismatching samples, in polygon
+	// coordinates.
+	Locations []polyclip.Point
+
+	grid              grid
+	expected, actual  []bool
+	subject, clipping polyclip.Polygon
+	result            polyclip.Polygon
+}
+
+// Oracle checks result of a Boolean operation (subject <op> clipping),
+// independently of the code of polyclip.Construct. All three polygons are
+// rasterized, using the even-odd fill rule, at the specified resolution
+// (number of samples along the longer side of their common bounding box), and
+// the rasters of subject and clipping are combined per sample according to op.
+// Any sample where this differs from the raster of result is reported.
+func Oracle(subject polyclip.Polygon, op polyclip.Op, clipping, result polyclip.Polygon, resolution int) *Mismatch {
+	g := newGrid(resolution, subject, clipping, result)
+	s, c := g.rasterize(subject), g.rasterize(clipping)
+	m := &Mismatch{
+		grid:     g,
+		expected: make([]bool, len(s)),
+		actual:   g.rasterize(result),
+		subject:  subject,
+		clipping: clipping,
+		result:   result,
+	}
+	for i := range s {
+		switch op {
+		case polyclip.UNION:
+			m.expected[i] = s[i] || c[i]
+		case polyclip.INTERSECTION:
+			m.expected[i] = s[i] && c[i]
+		case polyclip.DIFFERENCE:
+			m.expected[i] = s[i] && !c[i]
+		case polyclip.XOR:
+			m.expected[i] = s[i] != c[i]
+		}
+		if m.expected[i] != m.actual[i] {
+			m.Pixels++
+			m.Locations = append(m.Locations, g.sample(i%g.w, i/g.w))
+		}
+	}
+	m.Area = float64(m.Pixels) * g.step * g.step
+	return m
+}
+
+// Image visualizes the mismatch: the expected result is filled with gray,
+// outlines of subject, clipping and result polygons are drawn in blue, green
+// and white, and the mismatching samples are marked in red.
+func (m *Mismatch) Image() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, m.grid.w, m.grid.h))
+	for i, e := range m.expected {
+		if e {
+			img.Set(i%m.grid.w, i/m.grid.w, color.NRGBA{0x60, 0x60, 0x60, 0xff})
+		}
+	}
+	for i, p := range []polyclip.Polygon{m.subject, m.clipping, m.result} {
+		col := []color.NRGBA{{0, 0, 0xff, 0xff}, {0, 0xff, 0, 0xff}, {0xff, 0xff, 0xff, 0xff}}[i]
+		for _, c := range p {
+			polyutil.DrawPolyline(m.grid.toPixels(c), func(x, y int) { img.Set(x, y, col) })
+		}
+	}
+	for i := range m.expected {
+		if m.expected[i] != m.actual[i] {
+			img.Set(i%m.grid.w, i/m.grid.w, color.NRGBA{0xff, 0, 0, 0xff})
+		}
+	}
+	return img
+}
+
+// grid describes placement of samples over the bounding box of polygons
+type grid struct {
+	min  polyclip.Point
+	step float64
+	w, h int
+}
+
+func newGrid(resolution int, polys ...polyclip.Polygon) grid {
+	var bb *polyclip.Rectangle
+	for _, p := range polys {
+		if len(p) == 0 {
+			continue
+		}
+		pbb := p.BoundingBox()
+		if bb == nil {
+			bb = &pbb
+			continue
+		}
+		bb.Min.X, bb.Min.Y = math.Min(bb.Min.X, pbb.Min.X), math.Min(bb.Min.Y, pbb.Min.Y)
+		bb.Max.X, bb.Max.Y = math.Max(bb.Max.X, pbb.Max.X), math.Max(bb.Max.Y, pbb.Max.Y)
+	}
+	if bb == nil {
+		return grid{step: 1}
+	}
+	span := math.Max(bb.Max.X-bb.Min.X, bb.Max.Y-bb.Min.Y)
+	if span == 0 {
+		span = 1
+	}
+	g := grid{min: bb.Min, step: span / float64(resolution)}
+	g.w = int(math.Ceil((bb.Max.X - bb.Min.X) / g.step))
+	g.h = int(math.Ceil((bb.Max.Y - bb.Min.Y) / g.step))
+	return g
+}
+
+func (g grid) sample(x, y int) polyclip.Point {
+	return polyclip.Point{
+		X: g.min.X + (float64(x)+sampleOffset)*g.step,
+		Y: g.min.Y + (float64(y)+sampleOffset)*g.step,
+	}
+}
+
+func (g grid) toPixels(c polyclip.Contour) []polyclip.Point {
+	pts := make([]polyclip.Point, len(c))
+	for i, p := range c {
+		pts[i] = polyclip.Point{X: (p.X - g.min.X) / g.step, Y: (p.Y - g.min.Y) / g.step}
+	}
+	return pts
+}
+
+// rasterize returns, for each sample of the grid, whether it's inside p
+// according to the even-odd rule.
+func (g grid) rasterize(p polyclip.Polygon) []bool {
+	inside := make([]bool, g.w*g.h)
+	for y := 0; y < g.h; y++ {
+		sy := g.sample(0, y).Y
+		xs := []float64{}
+		for _, c := range p {
+			for i := range c {
+				a, b := c[i], c[(i+1)%len(c)]
+				if (a.Y <= sy) == (b.Y <= sy) {
+					continue
+				}
+				xs = append(xs, a.X+(sy-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 1; i < len(xs); i += 2 {
+			// fill samples with x in [xs[i-1], xs[i])
+			from := int(math.Ceil((xs[i-1]-g.min.X)/g.step - sampleOffset))
+			to := int(math.Ceil((xs[i]-g.min.X)/g.step - sampleOffset))
+			if from < 0 {
+				from = 0
+			}
+			for x := from; x < to && x < g.w; x++ {
+				inside[y*g.w+x] = true
+			}
+		}
+	}
+	return inside
+}
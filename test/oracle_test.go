@@ -0,0 +1,57 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"math"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+func TestOracle(t *T) {
+	subject := polyclip.Polygon{{{X: 1, Y: 1}, {X: 1, Y: 3}, {X: 3, Y: 3}, {X: 3, Y: 1}}}
+	clipping := polyclip.Polygon{{{X: 2, Y: 2}, {X: 2, Y: 4}, {X: 4, Y: 4}, {X: 4, Y: 2}}}
+
+	for _, op := range []polyclip.Op{polyclip.UNION, polyclip.INTERSECTION, polyclip.DIFFERENCE, polyclip.XOR} {
+		result := subject.Construct(op, clipping)
+		m := Oracle(subject, op, clipping, result, 300)
+		if m.Pixels != 0 {
+			t.Errorf("op %d: expected no mismatch for %v, got %d pixels at %v", op, result, m.Pixels, m.Locations)
+		}
+	}
+
+	// the intersection is the square (2,2)-(3,3); return a wrong one, with area 0.5 missing
+	wrong := polyclip.Polygon{{{X: 2, Y: 2}, {X: 2, Y: 3}, {X: 3, Y: 3}}}
+	m := Oracle(subject, polyclip.INTERSECTION, clipping, wrong, 300)
+	if math.Abs(m.Area-0.5) > 0.02 {
+		t.Errorf("expected mismatch area of about 0.5, got %v", m.Area)
+	}
+	for _, p := range m.Locations {
+		if p.X < 2 || p.X > 3 || p.Y < 2 || p.Y > p.X {
+			t.Errorf("unexpected mismatch location: %v", p)
+			break
+		}
+	}
+	img := m.Image()
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Errorf("expected 300x300 image, got: %v", b)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+// EdgeKind tells how an edge taking part in the sweep is treated by the
+// algorithm. Edges of the input polygons start as EdgeNormal; when two edges
+// are found to overlap, one of them is marked EdgeNonContributing and the
+// other one either EdgeSameTransition or EdgeDifferentTransition.
+type EdgeKind int
+
+const (
+	EdgeNormal              EdgeKind = EdgeKind(_EDGE_NORMAL)
+	EdgeNonContributing     EdgeKind = EdgeKind(_EDGE_NON_CONTRIBUTING)
+	EdgeSameTransition      EdgeKind = EdgeKind(_EDGE_SAME_TRANSITION)
+	EdgeDifferentTransition EdgeKind = EdgeKind(_EDGE_DIFFERENT_TRANSITION)
+)
+
+func (k EdgeKind) String() string {
+	switch k {
+	case EdgeNormal:
+		return "normal"
+	case EdgeNonContributing:
+		return "non-contributing"
+	case EdgeSameTransition:
+		return "same-transition"
+	case EdgeDifferentTransition:
+		return "different-transition"
+	}
+	return "unknown"
+}
+
+// TraceEdge is a snapshot of a single edge, as seen by the sweep.
+type TraceEdge struct {
+	Start, End Point
+	Clipping   bool // true if the edge comes from the clipping polygon, false if from the subject
+	Kind       EdgeKind
+}
+
+// SweepStep describes the state of the sweep right after an event was processed.
+type SweepStep struct {
+	// Event is the processed endpoint; the sweep line is at x = Event.X.
+	Event Point
+	// Left is true if the event was a left endpoint (the edge was inserted
+	// into the sweep line), and false if it was a right one (the edge was
+	// removed from the sweep line).
+	Left bool
+	// Active lists the edges in the sweep line, from bottom to top.
+	Active []TraceEdge
+	// Divided lists the intersection points at which edges were divided
+	// while processing the event.
+	Divided []Point
+	// Output lists the edges passed to the result while processing the event.
+	Output []TraceEdge
+}
+
+func traceEdge(e *endpoint) TraceEdge {
+	return TraceEdge{
+		Start:    e.p,
+		End:      e.other.p,
+		Clipping: e.polygonType == _CLIPPING,
+		Kind:     EdgeKind(e.edgeType),
+	}
+}
+
+// ConstructTraced works like Construct, but additionally calls trace after
+// each event processed by the sweep, allowing to inspect what the algorithm
+// did. It is intended mainly for debugging. The traced edges have float64
+// coordinates, which the algorithm works with.
+func (p PolygonOf[T]) ConstructTraced(operation Op, clipping PolygonOf[T], trace func(SweepStep)) PolygonOf[T] {
+	c := clipper{
+		subject:  p.float(),
+		clipping: clipping.float(),
+		trace:    trace,
+	}
+	return polygonOf[T](c.compute(operation))
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	. "testing"
+)
+
+func TestConstructTraced(t *T) {
+	subject := Polygon{{{1, 1}, {1, 3}, {3, 3}, {3, 1}}}
+	clipping := Polygon{{{2, 2}, {2, 4}, {4, 4}, {4, 2}}}
+
+	steps := []SweepStep{}
+	result := subject.ConstructTraced(UNION, clipping, func(s SweepStep) {
+		steps = append(steps, s)
+	})
+
+	// 8 edges, each divided once at an intersection point, give 12 edges
+	// and thus 24 events
+	verify(t, len(steps) == 24, "Expected 24 steps, got %d", len(steps))
+	verify(t, len(steps[len(steps)-1].Active) == 0, "Expected empty sweep line at the end, got: %v", steps[len(steps)-1].Active)
+
+	divided, output := 0, 0
+	for i, s := range steps {
+		if i > 0 {
+			verify(t, s.Event.X >= steps[i-1].Event.X, "Expected events sorted by X, got %v after %v", s.Event, steps[i-1].Event)
+		}
+		if s.Left {
+			verify(t, len(s.Output) == 0, "Expected no output on left event %d, got: %v", i, s.Output)
+		}
+		for j := 1; j < len(s.Active); j++ {
+			verify(t, !segmentCompare(
+				&endpoint{p: s.Active[j].Start, left: true, other: &endpoint{p: s.Active[j].End}},
+				&endpoint{p: s.Active[j-1].Start, left: true, other: &endpoint{p: s.Active[j-1].End}}),
+				"Expected sweep line sorted bottom to top in step %d, got: %v", i, s.Active)
+		}
+		divided += len(s.Divided)
+		output += len(s.Output)
+	}
+	// two intersection points, each dividing two edges
+	verify(t, divided == 4, "Expected 4 divisions, got %d", divided)
+	verify(t, output == result.NumVertices(), "Expected %d output edges, got %d", result.NumVertices(), output)
+}
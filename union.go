@@ -0,0 +1,154 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// UnionAllParallel computes the union of all the polygons, using up to
+// workers goroutines (or runtime.GOMAXPROCS(0), if workers is not positive).
+// Polygons are first sorted along a Hilbert curve by the centers of their
+// bounding boxes, so that polygons near each other end up in the same
+// groups, and then unioned in pairs up a balanced binary tree, with subtrees
+// processed concurrently. The shape of the tree only depends on the input, so
+// the result is the same no matter how the goroutines are scheduled, and for
+// any value of workers.
+func UnionAllParallel(polys []Polygon, workers int) Polygon {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	sorted := hilbertSort(polys)
+	if len(sorted) == 0 {
+		return Polygon{}
+	}
+	// tokens limits the number of additional goroutines running at once
+	tokens := make(chan struct{}, workers-1)
+	return unionRange(sorted, tokens)
+}
+
+// unionRange computes the union of polys, splitting them in halves
+func unionRange(polys []Polygon, tokens chan struct{}) Polygon {
+	switch len(polys) {
+	case 1:
+		// don't let the result share contours with the input
+		return polys[0].Clone()
+	case 2:
+		return polys[0].Construct(UNION, polys[1])
+	}
+	mid := len(polys) / 2
+	var left Polygon
+	select {
+	case tokens <- struct{}{}:
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			left = unionRange(polys[:mid], tokens)
+			<-tokens
+			wg.Done()
+		}()
+		right := unionRange(polys[mid:], tokens)
+		wg.Wait()
+		return left.Construct(UNION, right)
+	default:
+		left = unionRange(polys[:mid], tokens)
+		return left.Construct(UNION, unionRange(polys[mid:], tokens))
+	}
+}
+
+// hilbertOrder is the number of bits of each coordinate of cells used for
+// computing Hilbert indices.
+const hilbertOrder = 16
+
+// hilbertSort returns the non-empty polygons sorted by the Hilbert indices of
+// the centers of their bounding boxes. Polygons with equal indices keep their
+// original order.
+func hilbertSort(polys []Polygon) []Polygon {
+	type item struct {
+		poly   Polygon
+		center Point
+		index  uint64
+	}
+	items := []item{}
+	var bounds Rectangle
+	for _, p := range polys {
+		if p.NumVertices() == 0 {
+			continue
+		}
+		bb := p.BoundingBox()
+		it := item{poly: p, center: Point{(bb.Min.X + bb.Max.X) / 2, (bb.Min.Y + bb.Max.Y) / 2}}
+		if len(items) == 0 {
+			bounds = Rectangle{it.center, it.center}
+		} else {
+			bounds = bounds.union(Rectangle{it.center, it.center})
+		}
+		items = append(items, it)
+	}
+
+	const cells = 1 << hilbertOrder
+	cell := func(v, min, max float64) uint64 {
+		if max <= min {
+			return 0
+		}
+		c := uint64((v - min) / (max - min) * cells)
+		if c >= cells {
+			c = cells - 1
+		}
+		return c
+	}
+	for i := range items {
+		c := items[i].center
+		items[i].index = hilbertIndex(cell(c.X, bounds.Min.X, bounds.Max.X), cell(c.Y, bounds.Min.Y, bounds.Max.Y))
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].index < items[j].index })
+
+	sorted := make([]Polygon, len(items))
+	for i, it := range items {
+		sorted[i] = it.poly
+	}
+	return sorted
+}
+
+// hilbertIndex returns the distance along the Hilbert curve of the cell
+// (x, y) in a grid of 2^hilbertOrder x 2^hilbertOrder cells.
+func hilbertIndex(x, y uint64) uint64 {
+	var d uint64
+	for s := uint64(1) << (hilbertOrder - 1); s > 0; s /= 2 {
+		var rx, ry uint64
+		if x&s != 0 {
+			rx = 1
+		}
+		if y&s != 0 {
+			ry = 1
+		}
+		d += s * s * ((3 * rx) ^ ry)
+		// rotate the quadrant
+		if ry == 0 {
+			if rx == 1 {
+				x = 1<<hilbertOrder - 1 - x
+				y = 1<<hilbertOrder - 1 - y
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2011 Mateusz Czapliński
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package polyclip_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	. "testing"
+
+	"github.com/akavel/polyclip-go"
+)
+
+// footprints returns n random rectangles, some of them overlapping. The
+// rectangles are shifted by distinct fractions, so that their edges don't
+// overlap.
+func footprints(n int) []polyclip.Polygon {
+	r := rand.New(rand.NewSource(1))
+	const size = 200
+	polys := []polyclip.Polygon{}
+	for i := 0; i < n; i++ {
+		shift := float64(i+1) / float64(n+1)
+		x, y := float64(r.Intn(size-10))+shift, float64(r.Intn(size-10))+shift
+		w, h := float64(1+r.Intn(9)), float64(1+r.Intn(9))
+		polys = append(polys, polyclip.Polygon{{
+			{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h},
+		}})
+	}
+	return polys
+}
+
+// rectanglesArea returns the exact area of the union of rectangles.
+func rectanglesArea(rects []polyclip.Polygon) float64 {
+	xs, ys := []float64{}, []float64{}
+	for _, p := range rects {
+		bb := p.BoundingBox()
+		xs = append(xs, bb.Min.X, bb.Max.X)
+		ys = append(ys, bb.Min.Y, bb.Max.Y)
+	}
+	sort.Float64s(xs)
+	sort.Float64s(ys)
+	// sum the areas of cells of the grid made of all the edges, which are
+	// covered by any rectangle
+	area := 0.0
+	for i := 1; i < len(xs); i++ {
+		for j := 1; j < len(ys); j++ {
+			mid := polyclip.Point{X: (xs[i-1] + xs[i]) / 2, Y: (ys[j-1] + ys[j]) / 2}
+			for _, p := range rects {
+				bb := p.BoundingBox()
+				if bb.Min.X < mid.X && mid.X < bb.Max.X && bb.Min.Y < mid.Y && mid.Y < bb.Max.Y {
+					area += (xs[i] - xs[i-1]) * (ys[j] - ys[j-1])
+					break
+				}
+			}
+		}
+	}
+	return area
+}
+
+func TestUnionAllParallel(t *T) {
+	polys := footprints(150)
+	area := rectanglesArea(polys)
+	// empty polygons are skipped
+	polys = append(polys, polyclip.Polygon{}, nil)
+
+	var first string
+	for _, workers := range []int{1, 2, 3, 8, 0} {
+		result := polyclip.UnionAllParallel(polys, workers)
+		a := evenOddArea(result)
+		if math.Abs(a-area) > 1e-6 {
+			t.Errorf("Workers %d: expected area %v, got: %v", workers, area, a)
+		}
+		if first == "" {
+			first = fmt.Sprint(result)
+		}
+		if fmt.Sprint(result) != first {
+			t.Errorf("Workers %d: result differs from the one with 1 worker", workers)
+		}
+	}
+
+	result := polyclip.UnionAllParallel(nil, 4)
+	if len(result) != 0 {
+		t.Errorf("Expected empty result, got: %v", result)
+	}
+	single := polyclip.UnionAllParallel(polys[:1], 4)
+	if fmt.Sprint(single) != fmt.Sprint(polys[0]) {
+		t.Errorf("Expected %v, got: %v", polys[0], single)
+	}
+	single[0][0].X++
+	if single[0][0] == polys[0][0][0] {
+		t.Errorf("Expected result not to share points with the input")
+	}
+}
+
+func BenchmarkUnionAll(b *B) {
+	polys := footprints(500)
+	b.Run("sequential", func(b *B) {
+		for i := 0; i < b.N; i++ {
+			result := polyclip.Polygon{}
+			for _, p := range polys {
+				result = result.Construct(polyclip.UNION, p)
+			}
+		}
+	})
+	b.Run("parallel", func(b *B) {
+		for i := 0; i < b.N; i++ {
+			polyclip.UnionAllParallel(polys, 0)
+		}
+	})
+}